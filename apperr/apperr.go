@@ -0,0 +1,143 @@
+// Package apperr defines the errors reported to clients, each tagged with a stable machine-readable code
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code identifies a kind of error, clients can branch on it because it never changes once published
+type Code string
+
+const (
+	CodeInternal                  Code = "INTERNAL"
+	CodeInvalidArgument           Code = "INVALID_ARGUMENT"
+	CodeUnauthenticated           Code = "UNAUTHENTICATED"
+	CodeInvalidCredentials        Code = "INVALID_CREDENTIALS"
+	CodeInvalidToken              Code = "INVALID_TOKEN"
+	CodeTokenExpired              Code = "TOKEN_EXPIRED"
+	CodeTokenRevoked              Code = "TOKEN_REVOKED"
+	CodeSessionInvalid            Code = "SESSION_INVALID"
+	CodePermissionDenied          Code = "PERMISSION_DENIED"
+	CodeEmailNotVerified          Code = "EMAIL_NOT_VERIFIED"
+	CodeAccountNotOwned           Code = "ACCOUNT_NOT_OWNED"
+	CodeTransferNotOwned          Code = "TRANSFER_NOT_OWNED"
+	CodeUserNotFound              Code = "USER_NOT_FOUND"
+	CodeAccountNotFound           Code = "ACCOUNT_NOT_FOUND"
+	CodeTransferNotFound          Code = "TRANSFER_NOT_FOUND"
+	CodeSessionNotFound           Code = "SESSION_NOT_FOUND"
+	CodeUserAlreadyExists         Code = "USER_ALREADY_EXISTS"
+	CodeEmailAlreadyInUse         Code = "EMAIL_ALREADY_IN_USE"
+	CodeEmailAlreadyVerified      Code = "EMAIL_ALREADY_VERIFIED"
+	CodeInvalidResetToken         Code = "INVALID_RESET_TOKEN"
+	CodeInvalidVerificationToken  Code = "INVALID_VERIFICATION_TOKEN"
+	CodeCurrencyMismatch          Code = "CURRENCY_MISMATCH"
+	CodeUnsupportedCurrency       Code = "UNSUPPORTED_CURRENCY"
+	CodeInvalidAmount             Code = "INVALID_AMOUNT"
+	CodeExchangeRateNotFound      Code = "EXCHANGE_RATE_NOT_FOUND"
+	CodeInsufficientFunds         Code = "INSUFFICIENT_FUNDS"
+	CodeIdempotencyKeyReused      Code = "IDEMPOTENCY_KEY_REUSED"
+	CodeRequestInProgress         Code = "REQUEST_IN_PROGRESS"
+	CodeAccountInactive           Code = "ACCOUNT_INACTIVE"
+	CodeAccountNotEmpty           Code = "ACCOUNT_NOT_EMPTY"
+	CodeInvalidStatusTransition   Code = "INVALID_STATUS_TRANSITION"
+	CodeExternalTransferNotFound  Code = "EXTERNAL_TRANSFER_NOT_FOUND"
+	CodeDuplicateReference        Code = "DUPLICATE_EXTERNAL_REFERENCE"
+	CodeAlreadySettled            Code = "ALREADY_SETTLED"
+	CodeAlreadyFailed             Code = "ALREADY_FAILED"
+	CodeScheduledTransferNotFound Code = "SCHEDULED_TRANSFER_NOT_FOUND"
+	CodeScheduledTransferFinished Code = "SCHEDULED_TRANSFER_FINISHED"
+	CodeInvalidSchedule           Code = "INVALID_SCHEDULE"
+	CodeTransferNotReversible     Code = "TRANSFER_NOT_REVERSIBLE"
+)
+
+var httpStatuses = map[Code]int{
+	CodeInternal:                  http.StatusInternalServerError,
+	CodeInvalidArgument:           http.StatusBadRequest,
+	CodeUnauthenticated:           http.StatusUnauthorized,
+	CodeInvalidCredentials:        http.StatusUnauthorized,
+	CodeInvalidToken:              http.StatusUnauthorized,
+	CodeTokenExpired:              http.StatusUnauthorized,
+	CodeTokenRevoked:              http.StatusUnauthorized,
+	CodeSessionInvalid:            http.StatusUnauthorized,
+	CodePermissionDenied:          http.StatusForbidden,
+	CodeEmailNotVerified:          http.StatusForbidden,
+	CodeAccountNotOwned:           http.StatusForbidden,
+	CodeTransferNotOwned:          http.StatusForbidden,
+	CodeUserNotFound:              http.StatusNotFound,
+	CodeAccountNotFound:           http.StatusNotFound,
+	CodeTransferNotFound:          http.StatusNotFound,
+	CodeSessionNotFound:           http.StatusNotFound,
+	CodeUserAlreadyExists:         http.StatusConflict,
+	CodeEmailAlreadyInUse:         http.StatusConflict,
+	CodeEmailAlreadyVerified:      http.StatusBadRequest,
+	CodeInvalidResetToken:         http.StatusBadRequest,
+	CodeInvalidVerificationToken:  http.StatusBadRequest,
+	CodeCurrencyMismatch:          http.StatusBadRequest,
+	CodeUnsupportedCurrency:       http.StatusBadRequest,
+	CodeInvalidAmount:             http.StatusBadRequest,
+	CodeExchangeRateNotFound:      http.StatusBadRequest,
+	CodeInsufficientFunds:         http.StatusUnprocessableEntity,
+	CodeIdempotencyKeyReused:      http.StatusUnprocessableEntity,
+	CodeRequestInProgress:         http.StatusConflict,
+	CodeAccountInactive:           http.StatusUnprocessableEntity,
+	CodeAccountNotEmpty:           http.StatusConflict,
+	CodeInvalidStatusTransition:   http.StatusConflict,
+	CodeExternalTransferNotFound:  http.StatusNotFound,
+	CodeDuplicateReference:        http.StatusConflict,
+	CodeAlreadySettled:            http.StatusConflict,
+	CodeAlreadyFailed:             http.StatusConflict,
+	CodeScheduledTransferNotFound: http.StatusNotFound,
+	CodeScheduledTransferFinished: http.StatusConflict,
+	CodeInvalidSchedule:           http.StatusBadRequest,
+	CodeTransferNotReversible:     http.StatusConflict,
+}
+
+// HTTPStatus returns the http status the code is reported with, unknown codes are internal errors
+func (c Code) HTTPStatus() int {
+	if status, ok := httpStatuses[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error that is safe to show to clients, its message must never carry driver or system details
+type Error struct {
+	Code    Code
+	Message string
+	// Err is the underlying cause, it is kept for errors.Is but never shown to clients
+	Err error
+}
+
+// New creates an error with a code and a client facing message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf creates an error with a code and a formatted client facing message
+func Newf(code Code, format string, args ...interface{}) *Error {
+	return New(code, fmt.Sprintf(format, args...))
+}
+
+// Wrap tags err with a code, its message is shown to clients so it must only be used for errors produced by this service
+func Wrap(code Code, err error) *Error {
+	return &Error{Code: code, Message: err.Error(), Err: err}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// CodeOf returns the code of the first *Error in err's chain, errors without one are internal
+func CodeOf(err error) Code {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return CodeInternal
+}
@@ -0,0 +1,37 @@
+package apperr
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeOf(t *testing.T) {
+	err := New(CodeAccountNotFound, "account not found")
+	require.Equal(t, CodeAccountNotFound, CodeOf(err))
+
+	wrapped := fmt.Errorf("account [1]: %w", err)
+	require.Equal(t, CodeAccountNotFound, CodeOf(wrapped))
+	require.ErrorIs(t, wrapped, err)
+
+	require.Equal(t, CodeInternal, CodeOf(sql.ErrConnDone))
+}
+
+func TestWrap(t *testing.T) {
+	cause := fmt.Errorf("token is invalid")
+	err := Wrap(CodeInvalidToken, cause)
+
+	require.Equal(t, cause.Error(), err.Error())
+	require.ErrorIs(t, err, cause)
+	require.Equal(t, CodeInvalidToken, CodeOf(err))
+}
+
+func TestHTTPStatus(t *testing.T) {
+	require.Equal(t, http.StatusNotFound, CodeAccountNotFound.HTTPStatus())
+	require.Equal(t, http.StatusUnprocessableEntity, CodeInsufficientFunds.HTTPStatus())
+	require.Equal(t, http.StatusInternalServerError, CodeInternal.HTTPStatus())
+	require.Equal(t, http.StatusInternalServerError, Code("UNKNOWN").HTTPStatus())
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/simplebank/repo"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/ledger"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func init() {
+	addCommand(ledgerCmdFactory)
+}
+
+func ledgerCmdFactory(_ *config.Config, _ trace.TracerProvider, _ propagation.TextMapPropagator,
+	_ *otelhttp.Transport, db *sql.DB) *cobra.Command {
+	ledgerCmd := &cobra.Command{
+		Use:   "ledger",
+		Short: "Inspect the simplebank ledger",
+	}
+
+	var pageSize int32
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check that account balances and transfers reconcile with their entries",
+		// Execute only wraps top level commands, printError makes a ledger that doesn't reconcile exit non-zero
+		Run: printError(func(cmd *cobra.Command, args []string) error {
+			report, err := ledger.Verify(cmd.Context(), repo.New(db), pageSize)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			for _, drift := range report.Drifts {
+				fmt.Fprintf(out, "account %d: balance %s, entries sum to %s, drift %s\n",
+					drift.AccountID, drift.Balance, drift.EntriesBalance, drift.Drift)
+			}
+			for _, transfer := range report.UnbalancedTransfers {
+				fmt.Fprintf(out, "transfer %d: %d entries, debited %s of %s, credited %s of %s\n",
+					transfer.TransferID, transfer.EntryCount, transfer.Debited, transfer.Amount, transfer.Credited, transfer.ToAmount)
+			}
+			fmt.Fprintf(out, "checked %d accounts: %d drifting, %d unbalanced transfers\n",
+				report.AccountsChecked, len(report.Drifts), len(report.UnbalancedTransfers))
+
+			// a plain error so printError reports the message rather than a stack trace
+			if !report.Reconciles() {
+				return errors.New("ledger does not reconcile")
+			}
+			return nil
+		}),
+	}
+	verifyCmd.Flags().Int32Var(&pageSize, "page-size", ledger.DefaultPageSize, "number of accounts read per query")
+
+	ledgerCmd.AddCommand(verifyCmd)
+	return ledgerCmd
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/simplebank/repo"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/currency"
+	"github.com/simplebank/exchange"
+	"github.com/simplebank/scheduler"
+	"github.com/simplebank/service"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func init() {
+	addCommand(schedulerCmdFactory)
+}
+
+func schedulerCmdFactory(appConfig *config.Config, _ trace.TracerProvider, _ propagation.TextMapPropagator,
+	_ *otelhttp.Transport, db *sql.DB) *cobra.Command {
+	return &cobra.Command{
+		Use:   "scheduler",
+		Short: "Run simplebank scheduled transfers when they are due",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			fxRates, err := exchange.NewStaticRateProvider(appConfig.FXRates)
+			if err != nil {
+				return errors.Wrap(err, "cannot create exchange rate provider")
+			}
+
+			currencies, err := currency.NewRegistry(appConfig.Currencies)
+			if err != nil {
+				return errors.Wrap(err, "cannot create currency registry")
+			}
+
+			store := repo.NewStore(db)
+			bank := service.NewBank(store, fxRates, currencies)
+			err = scheduler.NewScheduler(appConfig, store, bank).Start(cmd.Context())
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		},
+	}
+}
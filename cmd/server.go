@@ -3,6 +3,7 @@ package cmd
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"net/http"
 	"os"
 
@@ -10,7 +11,9 @@ import (
 	"github.com/simplebank/repo"
 
 	"github.com/simplebank/config"
+	"github.com/simplebank/gapi"
 	"github.com/simplebank/server"
+	"github.com/simplebank/token"
 
 	"github.com/spf13/cobra"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
@@ -26,21 +29,59 @@ func serverCmdFactory(appConfig *config.Config, tracerProvider trace.TracerProvi
 	_ *otelhttp.Transport, db *sql.DB) *cobra.Command {
 	return &cobra.Command{
 		Use:   "server",
-		Short: "Run simplebank api server",
+		Short: "Run simplebank http and gRPC api servers",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if appConfig.RuntimeEnvironment == "cloud" && appConfig.Appenv == "backops" {
 				appConfig.Appenv = os.Getenv("GOOGLE_CLOUD_PROJECT")
 			}
 			store := repo.NewStore(db)
-			api, err := server.NewServer(appConfig, store)
+			// both apis share one denylist so a token revoked through either is refused by both
+			denylist, err := newDenylist(appConfig.TokenDenylist, store)
 			if err != nil {
 				return err
 			}
-			err = api.Serve(cmd.Context(), tracerProvider, propagator)
-			if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, http.ErrServerClosed) {
+			api, err := server.NewServer(appConfig, store, denylist)
+			if err != nil {
+				return err
+			}
+			grpcAPI, err := gapi.NewServer(appConfig, store, denylist)
+			if err != nil {
 				return err
 			}
-			return nil
+
+			ctx, cancel := context.WithCancel(cmd.Context())
+			defer cancel()
+
+			errs := make(chan error, 2)
+			go func() {
+				errs <- api.Serve(ctx, tracerProvider, propagator)
+			}()
+			go func() {
+				errs <- grpcAPI.Serve(ctx)
+			}()
+
+			// whichever server stops first takes the other one down with it
+			var serveErr error
+			for i := 0; i < 2; i++ {
+				err := <-errs
+				cancel()
+				if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, http.ErrServerClosed) && serveErr == nil {
+					serveErr = err
+				}
+			}
+			return serveErr
 		},
 	}
 }
+
+// newDenylist creates the token denylist configured by kind, either "memory" or "postgres"
+func newDenylist(kind string, querier repo.Querier) (token.Denylist, error) {
+	switch kind {
+	case "memory":
+		return token.NewMemoryDenylist(), nil
+	case "postgres":
+		return repo.NewPostgresDenylist(querier), nil
+	default:
+		return nil, fmt.Errorf("unknown token denylist %q", kind)
+	}
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/pkg/errors"
+	"github.com/simplebank/repo"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/mail"
+	"github.com/simplebank/worker"
+
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func init() {
+	addCommand(workerCmdFactory)
+}
+
+func workerCmdFactory(appConfig *config.Config, _ trace.TracerProvider, _ propagation.TextMapPropagator,
+	_ *otelhttp.Transport, db *sql.DB) *cobra.Command {
+	return &cobra.Command{
+		Use:   "worker",
+		Short: "Run simplebank background job worker",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var mailer mail.Mailer
+			var err error
+			switch appConfig.Mailer {
+			case "file":
+				mailer, err = mail.NewFileMailer(appConfig.MailDir, appConfig.MailFrom)
+				if err != nil {
+					return err
+				}
+			default:
+				return errors.Errorf("unknown mailer %q", appConfig.Mailer)
+			}
+
+			store := repo.NewStore(db)
+			processor := worker.NewProcessor(appConfig, store, mailer)
+			err = processor.Start(cmd.Context())
+			if err != nil && !errors.Is(err, context.Canceled) {
+				return err
+			}
+			return nil
+		},
+	}
+}
@@ -27,6 +27,7 @@ type Config struct {
 
 	ConfigFile string
 	Port       string
+	GRPCPort   string
 
 	// local
 	Testing bool
@@ -38,6 +39,28 @@ type Config struct {
 	TokenSymmetricKey    string
 	AccessTokenDuration  time.Duration
 	RefreshTokenDuration time.Duration
+	// where revoked access tokens are tracked, "memory" or "postgres"
+	TokenDenylist              string
+	PasswordResetTokenDuration time.Duration
+	VerifyEmailTokenDuration   time.Duration
+
+	// mail delivery, only "file" is supported which writes messages into MailDir
+	Mailer   string
+	MailDir  string
+	MailFrom string
+
+	// background job worker
+	WorkerConcurrency  int
+	WorkerPollInterval time.Duration
+
+	// how often the scheduler looks for due scheduled transfers
+	SchedulerPollInterval time.Duration
+
+	// ISO 4217 codes accounts can be opened in
+	Currencies []string
+
+	// exchange rates keyed by "FROM/TO" currency pair
+	FXRates map[string]string
 
 	// Server Timeouts
 	WriteTimeOut time.Duration
@@ -67,6 +90,7 @@ func New() (*Config, error) {
 	_, filePath, _, _ := runtime.Caller(0)
 	configFilepath := getEnv("CONFIG_FILE", filepath.Join(filepath.Dir(filePath), "..", "config.local.toml"))
 	port := getEnv("PORT", "5000")
+	grpcPort := getEnv("GRPC_PORT", "9090")
 	sbProcess := getEnv("SB_PROCESS", "main")
 	sbProject := getEnv("SB_PROJECT", "simplebank")
 	testingStr := getEnv("TESTING", "false")
@@ -89,6 +113,7 @@ func New() (*Config, error) {
 	config.SbProcess = sbProcess
 	config.SbProject = sbProject
 	config.Port = port
+	config.GRPCPort = grpcPort
 	config.RuntimeEnvironment = runtimeEnvironment
 	config.Version = version
 
@@ -120,6 +145,46 @@ func (c *Config) InitDefaults() {
 	c.SbProject = getEnv("SB_PROJECT", "unknown")
 	c.RuntimeEnvironment = getEnv("RUNTIME_ENVIRONMENT", "local")
 
+	if c.TokenDenylist == "" {
+		c.TokenDenylist = "memory"
+	}
+
+	if c.PasswordResetTokenDuration == 0 {
+		c.PasswordResetTokenDuration = time.Minute * 30
+	}
+
+	if c.VerifyEmailTokenDuration == 0 {
+		c.VerifyEmailTokenDuration = time.Hour * 24
+	}
+
+	if c.Mailer == "" {
+		c.Mailer = "file"
+	}
+
+	if c.MailDir == "" {
+		c.MailDir = filepath.Join(os.TempDir(), "simplebank-mail")
+	}
+
+	if c.MailFrom == "" {
+		c.MailFrom = "no-reply@simplebank.local"
+	}
+
+	if c.WorkerConcurrency == 0 {
+		c.WorkerConcurrency = 4
+	}
+
+	if c.WorkerPollInterval == 0 {
+		c.WorkerPollInterval = time.Second
+	}
+
+	if c.SchedulerPollInterval == 0 {
+		c.SchedulerPollInterval = 10 * time.Second
+	}
+
+	if len(c.Currencies) == 0 {
+		c.Currencies = []string{"USD", "EUR", "CAD"}
+	}
+
 	if c.LogLevel == "" {
 		c.LogLevel = "INFO"
 	}
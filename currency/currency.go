@@ -0,0 +1,96 @@
+package currency
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency, amounts in it are stored as integers of its minor unit
+type Currency struct {
+	// alphabetic code, such as "USD"
+	Code string
+	// numeric code, kept as a string because of its leading zeros
+	Numeric string
+	Name    string
+	// number of decimal places between the minor and the major unit, 2 for cents
+	MinorUnits int
+}
+
+// iso4217 lists the currencies the bank knows about, which ones accounts can be opened in is up to the Registry
+var iso4217 = map[string]Currency{
+	"AED": {Code: "AED", Numeric: "784", Name: "UAE Dirham", MinorUnits: 2},
+	"AUD": {Code: "AUD", Numeric: "036", Name: "Australian Dollar", MinorUnits: 2},
+	"BHD": {Code: "BHD", Numeric: "048", Name: "Bahraini Dinar", MinorUnits: 3},
+	"BRL": {Code: "BRL", Numeric: "986", Name: "Brazilian Real", MinorUnits: 2},
+	"CAD": {Code: "CAD", Numeric: "124", Name: "Canadian Dollar", MinorUnits: 2},
+	"CHF": {Code: "CHF", Numeric: "756", Name: "Swiss Franc", MinorUnits: 2},
+	"CLP": {Code: "CLP", Numeric: "152", Name: "Chilean Peso", MinorUnits: 0},
+	"CNY": {Code: "CNY", Numeric: "156", Name: "Yuan Renminbi", MinorUnits: 2},
+	"CZK": {Code: "CZK", Numeric: "203", Name: "Czech Koruna", MinorUnits: 2},
+	"DKK": {Code: "DKK", Numeric: "208", Name: "Danish Krone", MinorUnits: 2},
+	"EUR": {Code: "EUR", Numeric: "978", Name: "Euro", MinorUnits: 2},
+	"GBP": {Code: "GBP", Numeric: "826", Name: "Pound Sterling", MinorUnits: 2},
+	"HKD": {Code: "HKD", Numeric: "344", Name: "Hong Kong Dollar", MinorUnits: 2},
+	"HUF": {Code: "HUF", Numeric: "348", Name: "Forint", MinorUnits: 2},
+	"IDR": {Code: "IDR", Numeric: "360", Name: "Rupiah", MinorUnits: 2},
+	"ILS": {Code: "ILS", Numeric: "376", Name: "New Israeli Sheqel", MinorUnits: 2},
+	"INR": {Code: "INR", Numeric: "356", Name: "Indian Rupee", MinorUnits: 2},
+	"ISK": {Code: "ISK", Numeric: "352", Name: "Iceland Krona", MinorUnits: 0},
+	"JOD": {Code: "JOD", Numeric: "400", Name: "Jordanian Dinar", MinorUnits: 3},
+	"JPY": {Code: "JPY", Numeric: "392", Name: "Yen", MinorUnits: 0},
+	"KRW": {Code: "KRW", Numeric: "410", Name: "Won", MinorUnits: 0},
+	"KWD": {Code: "KWD", Numeric: "414", Name: "Kuwaiti Dinar", MinorUnits: 3},
+	"MXN": {Code: "MXN", Numeric: "484", Name: "Mexican Peso", MinorUnits: 2},
+	"NOK": {Code: "NOK", Numeric: "578", Name: "Norwegian Krone", MinorUnits: 2},
+	"NZD": {Code: "NZD", Numeric: "554", Name: "New Zealand Dollar", MinorUnits: 2},
+	"OMR": {Code: "OMR", Numeric: "512", Name: "Rial Omani", MinorUnits: 3},
+	"PHP": {Code: "PHP", Numeric: "608", Name: "Philippine Peso", MinorUnits: 2},
+	"PLN": {Code: "PLN", Numeric: "985", Name: "Zloty", MinorUnits: 2},
+	"SAR": {Code: "SAR", Numeric: "682", Name: "Saudi Riyal", MinorUnits: 2},
+	"SEK": {Code: "SEK", Numeric: "752", Name: "Swedish Krona", MinorUnits: 2},
+	"SGD": {Code: "SGD", Numeric: "702", Name: "Singapore Dollar", MinorUnits: 2},
+	"THB": {Code: "THB", Numeric: "764", Name: "Baht", MinorUnits: 2},
+	"TND": {Code: "TND", Numeric: "788", Name: "Tunisian Dinar", MinorUnits: 3},
+	"TRY": {Code: "TRY", Numeric: "949", Name: "Turkish Lira", MinorUnits: 2},
+	"TWD": {Code: "TWD", Numeric: "901", Name: "New Taiwan Dollar", MinorUnits: 2},
+	"UGX": {Code: "UGX", Numeric: "800", Name: "Uganda Shilling", MinorUnits: 0},
+	"USD": {Code: "USD", Numeric: "840", Name: "US Dollar", MinorUnits: 2},
+	"VND": {Code: "VND", Numeric: "704", Name: "Dong", MinorUnits: 0},
+	"XOF": {Code: "XOF", Numeric: "952", Name: "CFA Franc BCEAO", MinorUnits: 0},
+	"ZAR": {Code: "ZAR", Numeric: "710", Name: "Rand", MinorUnits: 2},
+}
+
+// Lookup returns the ISO 4217 currency with the given alphabetic code, whether or not it is enabled
+func Lookup(code string) (Currency, bool) {
+	currency, ok := iso4217[code]
+	return currency, ok
+}
+
+// Format renders an amount of minor units as a decimal string in the major unit, 1234 USD is "12.34"
+func (c Currency) Format(amount int64) string {
+	digits := strconv.FormatInt(amount, 10)
+	sign := ""
+	if amount < 0 {
+		sign, digits = "-", digits[1:]
+	}
+
+	if c.MinorUnits == 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= c.MinorUnits {
+		digits = strings.Repeat("0", c.MinorUnits-len(digits)+1) + digits
+	}
+	split := len(digits) - c.MinorUnits
+	return sign + digits[:split] + "." + digits[split:]
+}
+
+// FormatAmount formats an amount in the currency with the given code followed by the code, such as "12.34 USD".
+// Unknown codes fall back to the raw amount of minor units.
+func FormatAmount(amount int64, code string) string {
+	currency, ok := Lookup(code)
+	if !ok {
+		return strconv.FormatInt(amount, 10) + " " + code
+	}
+	return currency.Format(amount) + " " + code
+}
@@ -0,0 +1,37 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	usd, ok := Lookup("USD")
+	require.True(t, ok)
+	require.Equal(t, "12.34", usd.Format(1234))
+	require.Equal(t, "0.05", usd.Format(5))
+	require.Equal(t, "0.00", usd.Format(0))
+	require.Equal(t, "-1.50", usd.Format(-150))
+
+	jpy, ok := Lookup("JPY")
+	require.True(t, ok)
+	require.Equal(t, "1234", jpy.Format(1234))
+
+	kwd, ok := Lookup("KWD")
+	require.True(t, ok)
+	require.Equal(t, "1.234", kwd.Format(1234))
+
+	require.Equal(t, "12.34 EUR", FormatAmount(1234, "EUR"))
+	require.Equal(t, "1234 XXX", FormatAmount(1234, "XXX"))
+}
+
+func TestLookup(t *testing.T) {
+	for code, currency := range iso4217 {
+		require.Equal(t, code, currency.Code)
+		require.Len(t, currency.Numeric, 3)
+	}
+
+	_, ok := Lookup("XXX")
+	require.False(t, ok)
+}
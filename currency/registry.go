@@ -0,0 +1,59 @@
+package currency
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrUnsupportedCurrency is returned for currencies that are unknown or not enabled
+var ErrUnsupportedCurrency = errors.New("unsupported currency")
+
+// Registry holds the currencies accounts can be opened and moved in
+type Registry struct {
+	enabled map[string]Currency
+}
+
+// NewRegistry creates a registry enabling the given ISO 4217 codes
+func NewRegistry(codes []string) (*Registry, error) {
+	registry := &Registry{
+		enabled: make(map[string]Currency, len(codes)),
+	}
+
+	for _, code := range codes {
+		currency, ok := Lookup(code)
+		if !ok {
+			return nil, fmt.Errorf("invalid currency %q: not an ISO 4217 code", code)
+		}
+		registry.enabled[code] = currency
+	}
+
+	return registry, nil
+}
+
+// Get returns an enabled currency
+func (registry *Registry) Get(code string) (Currency, error) {
+	currency, ok := registry.enabled[code]
+	if !ok {
+		return Currency{}, fmt.Errorf("%s: %w", code, ErrUnsupportedCurrency)
+	}
+	return currency, nil
+}
+
+// IsSupported returns true if accounts can hold the currency
+func (registry *Registry) IsSupported(code string) bool {
+	_, ok := registry.enabled[code]
+	return ok
+}
+
+// Currencies returns the enabled currencies sorted by code
+func (registry *Registry) Currencies() []Currency {
+	currencies := make([]Currency, 0, len(registry.enabled))
+	for _, currency := range registry.enabled {
+		currencies = append(currencies, currency)
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+	return currencies
+}
@@ -0,0 +1,31 @@
+package currency
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	registry, err := NewRegistry([]string{"USD", "JPY"})
+	require.NoError(t, err)
+
+	require.True(t, registry.IsSupported("USD"))
+	require.True(t, registry.IsSupported("JPY"))
+	require.False(t, registry.IsSupported("EUR"))
+
+	jpy, err := registry.Get("JPY")
+	require.NoError(t, err)
+	require.Equal(t, 0, jpy.MinorUnits)
+
+	_, err = registry.Get("EUR")
+	require.ErrorIs(t, err, ErrUnsupportedCurrency)
+
+	currencies := registry.Currencies()
+	require.Len(t, currencies, 2)
+	require.Equal(t, "JPY", currencies[0].Code)
+	require.Equal(t, "USD", currencies[1].Code)
+
+	_, err = NewRegistry([]string{"USD", "XXX"})
+	require.Error(t, err)
+}
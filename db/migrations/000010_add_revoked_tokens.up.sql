@@ -0,0 +1,11 @@
+CREATE TABLE "revoked_tokens" (
+    "id" uuid PRIMARY KEY,
+    "expires_at" timestamptz NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "revoked_tokens" ("expires_at");
+
+COMMENT ON COLUMN "revoked_tokens"."id" IS 'id of the token payload';
+
+COMMENT ON COLUMN "revoked_tokens"."expires_at" IS 'expiry of the token itself, the row is useless afterwards';
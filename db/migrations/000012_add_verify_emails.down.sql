@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "verify_emails";
+
+ALTER TABLE IF EXISTS "users" DROP COLUMN IF EXISTS "pending_email";
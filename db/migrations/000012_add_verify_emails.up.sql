@@ -0,0 +1,21 @@
+ALTER TABLE "users" ADD COLUMN "pending_email" varchar;
+
+COMMENT ON COLUMN "users"."pending_email" IS 'requested email address, replaces email once verified';
+
+CREATE TABLE "verify_emails" (
+    "id" bigserial PRIMARY KEY,
+    "username" varchar NOT NULL,
+    "email" varchar NOT NULL,
+    "token_hash" varchar UNIQUE NOT NULL,
+    "expires_at" timestamptz NOT NULL,
+    "used_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "verify_emails" ADD FOREIGN KEY ("username") REFERENCES "users" ("username");
+
+CREATE INDEX ON "verify_emails" ("username");
+
+COMMENT ON COLUMN "verify_emails"."token_hash" IS 'sha256 of the token sent to the user, the token itself is never stored';
+
+COMMENT ON COLUMN "verify_emails"."used_at" IS 'set when the token is redeemed, a token can only be used once';
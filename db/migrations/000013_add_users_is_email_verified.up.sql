@@ -0,0 +1,3 @@
+ALTER TABLE "users" ADD COLUMN "is_email_verified" boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN "users"."is_email_verified" IS 'set once the user redeems a verify_emails token for their address';
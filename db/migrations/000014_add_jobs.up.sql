@@ -0,0 +1,23 @@
+CREATE TABLE "jobs" (
+    "id" bigserial PRIMARY KEY,
+    "kind" varchar NOT NULL,
+    "payload" jsonb NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "attempts" integer NOT NULL DEFAULT 0,
+    "max_attempts" integer NOT NULL,
+    "run_at" timestamptz NOT NULL DEFAULT (now()),
+    "locked_at" timestamptz,
+    "last_error" varchar,
+    "finished_at" timestamptz,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "jobs" ADD CONSTRAINT "jobs_status_check" CHECK ("status" IN ('pending', 'running', 'done', 'dead'));
+
+CREATE INDEX ON "jobs" ("run_at") WHERE "status" IN ('pending', 'running');
+
+COMMENT ON COLUMN "jobs"."status" IS 'pending until claimed, running while a worker holds it, then done or dead once out of attempts';
+
+COMMENT ON COLUMN "jobs"."run_at" IS 'earliest time the job may be claimed, pushed back on every retry';
+
+COMMENT ON COLUMN "jobs"."locked_at" IS 'when a worker claimed the job, running jobs locked for too long are reclaimed';
@@ -0,0 +1,3 @@
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "to_currency";
+
+ALTER TABLE IF EXISTS "transfers" DROP COLUMN IF EXISTS "currency";
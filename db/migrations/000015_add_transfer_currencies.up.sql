@@ -0,0 +1,15 @@
+ALTER TABLE "transfers" ADD COLUMN "currency" varchar;
+
+ALTER TABLE "transfers" ADD COLUMN "to_currency" varchar;
+
+UPDATE "transfers" t SET "currency" = fa."currency", "to_currency" = ta."currency"
+FROM "accounts" fa, "accounts" ta
+WHERE fa."id" = t."from_account_id" AND ta."id" = t."to_account_id";
+
+ALTER TABLE "transfers" ALTER COLUMN "currency" SET NOT NULL;
+
+ALTER TABLE "transfers" ALTER COLUMN "to_currency" SET NOT NULL;
+
+COMMENT ON COLUMN "transfers"."currency" IS 'currency of amount, the source account''s currency';
+
+COMMENT ON COLUMN "transfers"."to_currency" IS 'currency of to_amount, the destination account''s currency';
@@ -0,0 +1,15 @@
+ALTER TABLE "entries" ADD COLUMN "transfer_id" bigint;
+
+-- entries written before this migration are matched to their transfer by the transaction they were created in
+UPDATE "entries" e SET "transfer_id" = t."id"
+FROM "transfers" t
+WHERE e."transfer_id" IS NULL
+  AND e."created_at" = t."created_at"
+  AND ((e."account_id" = t."from_account_id" AND e."amount" = -t."amount") OR
+       (e."account_id" = t."to_account_id" AND e."amount" = t."to_amount"));
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+CREATE INDEX ON "entries" ("transfer_id");
+
+COMMENT ON COLUMN "entries"."transfer_id" IS 'transfer the entry is one side of, every transfer has a debit and a credit entry';
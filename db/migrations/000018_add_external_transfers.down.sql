@@ -0,0 +1,9 @@
+ALTER TABLE IF EXISTS "entries" DROP COLUMN IF EXISTS "external_transfer_id";
+
+DROP TABLE IF EXISTS "external_transfers";
+
+DELETE FROM "entries" WHERE "account_id" IN (SELECT "id" FROM "accounts" WHERE "owner" = 'simplebank');
+
+DELETE FROM "accounts" WHERE "owner" = 'simplebank';
+
+DELETE FROM "users" WHERE "username" = 'simplebank';
@@ -0,0 +1,55 @@
+-- owns the clearing accounts money enters and leaves the bank through, it has no password so nobody can log in as it
+INSERT INTO "users" ("username", "hashed_password", "full_name", "email")
+VALUES ('simplebank', '', 'Simple Bank clearing', 'clearing@simplebank.invalid');
+
+CREATE TABLE "external_transfers" (
+    "id" bigserial PRIMARY KEY,
+    "kind" varchar NOT NULL,
+    "account_id" bigint NOT NULL,
+    "clearing_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" varchar NOT NULL,
+    "external_reference" varchar NOT NULL,
+    "status" varchar NOT NULL DEFAULT 'pending',
+    "created_by" varchar NOT NULL,
+    "created_at" timestamptz NOT NULL DEFAULT (now()),
+    "settled_at" timestamptz,
+    "posted_at" timestamptz,
+    "failed_at" timestamptz
+);
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("clearing_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "external_transfers" ADD FOREIGN KEY ("created_by") REFERENCES "users" ("username");
+
+ALTER TABLE "external_transfers" ADD CONSTRAINT "external_transfers_kind_check" CHECK ("kind" IN ('deposit', 'withdrawal'));
+
+ALTER TABLE "external_transfers" ADD CONSTRAINT "external_transfers_status_check" CHECK ("status" IN ('pending', 'settled', 'failed'));
+
+ALTER TABLE "external_transfers" ADD CONSTRAINT "external_transfers_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "external_transfers" ADD CONSTRAINT "external_transfers_reference_key" UNIQUE ("kind", "external_reference");
+
+CREATE INDEX ON "external_transfers" ("account_id");
+
+ALTER TABLE "entries" ADD COLUMN "external_transfer_id" bigint;
+
+ALTER TABLE "entries" ADD FOREIGN KEY ("external_transfer_id") REFERENCES "external_transfers" ("id");
+
+COMMENT ON COLUMN "external_transfers"."kind" IS 'deposit credits the account from the clearing account, withdrawal debits it into the clearing account';
+
+COMMENT ON COLUMN "external_transfers"."clearing_account_id" IS 'system account of the same currency the money comes from or goes to';
+
+COMMENT ON COLUMN "external_transfers"."external_reference" IS 'id of the operation in the system the money comes from or goes to, unique per kind';
+
+COMMENT ON COLUMN "external_transfers"."status" IS 'pending until the external system confirms the operation, then settled, or failed when it is rejected';
+
+COMMENT ON COLUMN "external_transfers"."created_by" IS 'staff member who recorded the operation';
+
+COMMENT ON COLUMN "external_transfers"."posted_at" IS 'when the entries were posted, pending deposits are only posted once settled while withdrawals are posted right away';
+
+COMMENT ON COLUMN "external_transfers"."failed_at" IS 'when the operation was rejected, money already posted is given back by compensating entries';
+
+COMMENT ON COLUMN "entries"."external_transfer_id" IS 'deposit or withdrawal the entry is one side of';
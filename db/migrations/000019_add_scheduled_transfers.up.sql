@@ -0,0 +1,59 @@
+CREATE TABLE "scheduled_transfers" (
+    "id" bigserial PRIMARY KEY,
+    "owner" varchar NOT NULL,
+    "from_account_id" bigint NOT NULL,
+    "to_account_id" bigint NOT NULL,
+    "amount" bigint NOT NULL,
+    "currency" varchar NOT NULL,
+    "schedule" varchar,
+    "next_run_at" timestamptz,
+    "status" varchar NOT NULL DEFAULT 'active',
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("owner") REFERENCES "users" ("username");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("from_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD FOREIGN KEY ("to_account_id") REFERENCES "accounts" ("id");
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_status_check" CHECK ("status" IN ('active', 'paused', 'completed', 'cancelled'));
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_amount_positive" CHECK ("amount" > 0);
+
+ALTER TABLE "scheduled_transfers" ADD CONSTRAINT "scheduled_transfers_next_run_check" CHECK (("next_run_at" IS NULL) = ("status" IN ('completed', 'cancelled')));
+
+CREATE INDEX ON "scheduled_transfers" ("owner", "id");
+
+CREATE INDEX ON "scheduled_transfers" ("next_run_at") WHERE "status" = 'active';
+
+CREATE TABLE "scheduled_transfer_runs" (
+    "id" bigserial PRIMARY KEY,
+    "scheduled_transfer_id" bigint NOT NULL,
+    "scheduled_for" timestamptz NOT NULL,
+    "transfer_id" bigint,
+    "error" varchar,
+    "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("scheduled_transfer_id") REFERENCES "scheduled_transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD FOREIGN KEY ("transfer_id") REFERENCES "transfers" ("id");
+
+ALTER TABLE "scheduled_transfer_runs" ADD CONSTRAINT "scheduled_transfer_runs_occurrence_key" UNIQUE ("scheduled_transfer_id", "scheduled_for");
+
+ALTER TABLE "scheduled_transfer_runs" ADD CONSTRAINT "scheduled_transfer_runs_outcome_check" CHECK (("transfer_id" IS NULL) <> ("error" IS NULL));
+
+COMMENT ON COLUMN "scheduled_transfers"."owner" IS 'user the transfers are made on behalf of, they must own the source account';
+
+COMMENT ON COLUMN "scheduled_transfers"."schedule" IS 'standard cron expression or descriptor such as @monthly or @every 24h, NULL for a one-off transfer';
+
+COMMENT ON COLUMN "scheduled_transfers"."next_run_at" IS 'occurrence the scheduler executes next, NULL once completed or cancelled';
+
+COMMENT ON COLUMN "scheduled_transfers"."status" IS 'active while occurrences are executed, paused to skip them, completed after a one-off ran, cancelled when deleted';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."scheduled_for" IS 'occurrence that was executed, unique per scheduled transfer so it never runs twice';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."transfer_id" IS 'transfer made for the occurrence, NULL when it failed';
+
+COMMENT ON COLUMN "scheduled_transfer_runs"."error" IS 'why the occurrence failed, NULL when it succeeded';
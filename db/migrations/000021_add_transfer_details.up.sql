@@ -0,0 +1,13 @@
+ALTER TABLE "transfers" ADD COLUMN "description" varchar;
+
+ALTER TABLE "transfers" ADD COLUMN "client_reference" varchar;
+
+ALTER TABLE "transfers" ADD COLUMN "metadata" jsonb NOT NULL DEFAULT '{}';
+
+CREATE INDEX ON "transfers" ("client_reference");
+
+COMMENT ON COLUMN "transfers"."description" IS 'memo shown to both sides of the transfer';
+
+COMMENT ON COLUMN "transfers"."client_reference" IS 'reference the sender attached to reconcile the transfer with their own records';
+
+COMMENT ON COLUMN "transfers"."metadata" IS 'free-form string keys and values the sender attached';
@@ -0,0 +1,81 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/simplebank/currency"
+)
+
+// RatePrecision is the number of decimal places rates are rounded to before they are applied
+const RatePrecision = 8
+
+// ErrRateNotFound is returned when no rate is known for a currency pair
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+// FXRateProvider is an interface for looking up exchange rates
+type FXRateProvider interface {
+	// Rate returns the rate used to convert an amount in the from currency into the to currency
+	Rate(ctx context.Context, from string, to string) (Rate, error)
+}
+
+// Rate is the price of one unit of From expressed in To
+type Rate struct {
+	From  string
+	To    string
+	Value *big.Rat
+}
+
+// NewRate creates a rate for a currency pair, rounded to RatePrecision decimal places
+func NewRate(from string, to string, value *big.Rat) (Rate, error) {
+	if value.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("invalid %s/%s rate %s: must be positive", from, to, value.FloatString(RatePrecision))
+	}
+
+	rounded, ok := new(big.Rat).SetString(value.FloatString(RatePrecision))
+	if !ok || rounded.Sign() <= 0 {
+		return Rate{}, fmt.Errorf("invalid %s/%s rate: too small to be represented", from, to)
+	}
+
+	return Rate{From: from, To: to, Value: rounded}, nil
+}
+
+// String formats the rate the way it is recorded on transfers
+func (r Rate) String() string {
+	return r.Value.FloatString(RatePrecision)
+}
+
+// Convert applies the rate to an amount of From minor units and returns To minor units,
+// rounding half away from zero. The rate is quoted in major units, so the result is scaled
+// when the currencies have a different number of decimal places.
+func (r Rate) Convert(amount int64) (int64, error) {
+	from, ok := currency.Lookup(r.From)
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %s", r.From)
+	}
+	to, ok := currency.Lookup(r.To)
+	if !ok {
+		return 0, fmt.Errorf("unknown currency %s", r.To)
+	}
+
+	// moves the result into minor units of To, 1/100 for USD to JPY and 10 for USD to BHD
+	scale := new(big.Rat).SetFrac(pow10(to.MinorUnits), pow10(from.MinorUnits))
+	product := new(big.Rat).Mul(new(big.Rat).SetInt64(amount), r.Value)
+	product.Mul(product, scale)
+
+	quotient, remainder := new(big.Int).QuoRem(product.Num(), product.Denom(), new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2)).Cmp(product.Denom()) >= 0 {
+		quotient.Add(quotient, big.NewInt(int64(product.Sign())))
+	}
+
+	if !quotient.IsInt64() {
+		return 0, fmt.Errorf("converted amount overflows: %s %d at %s", r.From, amount, r)
+	}
+	return quotient.Int64(), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
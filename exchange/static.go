@@ -0,0 +1,62 @@
+package exchange
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// StaticRateProvider serves exchange rates from a fixed table
+type StaticRateProvider struct {
+	rates map[string]Rate
+}
+
+// NewStaticRateProvider creates a new StaticRateProvider from rates keyed by "FROM/TO" pairs.
+// Only one direction of a pair needs to be listed, the inverse is derived from it.
+func NewStaticRateProvider(rates map[string]string) (FXRateProvider, error) {
+	provider := &StaticRateProvider{
+		rates: make(map[string]Rate, len(rates)),
+	}
+
+	for pair, value := range rates {
+		currencies := strings.Split(pair, "/")
+		if len(currencies) != 2 || len(currencies[0]) == 0 || len(currencies[1]) == 0 {
+			return nil, fmt.Errorf("invalid currency pair %q: must be formatted as FROM/TO", pair)
+		}
+
+		parsed, ok := new(big.Rat).SetString(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s rate %q: not a decimal number", pair, value)
+		}
+
+		rate, err := NewRate(currencies[0], currencies[1], parsed)
+		if err != nil {
+			return nil, err
+		}
+		provider.rates[pairKey(rate.From, rate.To)] = rate
+	}
+
+	return provider, nil
+}
+
+// Rate returns the rate for a currency pair, falling back to the inverse of the opposite pair
+func (provider *StaticRateProvider) Rate(_ context.Context, from string, to string) (Rate, error) {
+	if from == to {
+		return Rate{From: from, To: to, Value: big.NewRat(1, 1)}, nil
+	}
+
+	if rate, ok := provider.rates[pairKey(from, to)]; ok {
+		return rate, nil
+	}
+
+	if rate, ok := provider.rates[pairKey(to, from)]; ok {
+		return NewRate(from, to, new(big.Rat).Inv(rate.Value))
+	}
+
+	return Rate{}, fmt.Errorf("%s/%s: %w", from, to, ErrRateNotFound)
+}
+
+func pairKey(from string, to string) string {
+	return from + "/" + to
+}
@@ -0,0 +1,140 @@
+package exchange
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaticRateProvider(t *testing.T) {
+	provider, err := NewStaticRateProvider(map[string]string{
+		"USD/EUR": "0.92",
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	rate, err := provider.Rate(ctx, "USD", "EUR")
+	require.NoError(t, err)
+	require.Equal(t, "0.92000000", rate.String())
+
+	converted, err := rate.Convert(1000)
+	require.NoError(t, err)
+	require.Equal(t, int64(920), converted)
+
+	inverse, err := provider.Rate(ctx, "EUR", "USD")
+	require.NoError(t, err)
+	require.Equal(t, "EUR", inverse.From)
+	require.Equal(t, "USD", inverse.To)
+	require.Equal(t, "1.08695652", inverse.String())
+
+	converted, err = inverse.Convert(920)
+	require.NoError(t, err)
+	require.Equal(t, int64(1000), converted)
+
+	same, err := provider.Rate(ctx, "CAD", "CAD")
+	require.NoError(t, err)
+	require.Equal(t, "1.00000000", same.String())
+
+	_, err = provider.Rate(ctx, "USD", "CAD")
+	require.ErrorIs(t, err, ErrRateNotFound)
+}
+
+func TestInvalidStaticRates(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rates map[string]string
+	}{
+		{name: "MissingSeparator", rates: map[string]string{"USDEUR": "0.92"}},
+		{name: "EmptyCurrency", rates: map[string]string{"USD/": "0.92"}},
+		{name: "NotANumber", rates: map[string]string{"USD/EUR": "abc"}},
+		{name: "Negative", rates: map[string]string{"USD/EUR": "-0.92"}},
+		{name: "Zero", rates: map[string]string{"USD/EUR": "0"}},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			provider, err := NewStaticRateProvider(tc.rates)
+			require.Error(t, err)
+			require.Nil(t, provider)
+		})
+	}
+}
+
+func TestRateConvertRounding(t *testing.T) {
+	provider, err := NewStaticRateProvider(map[string]string{
+		"USD/EUR": "0.5",
+	})
+	require.NoError(t, err)
+
+	rate, err := provider.Rate(context.Background(), "USD", "EUR")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		amount   int64
+		expected int64
+	}{
+		{amount: 10, expected: 5},
+		{amount: 11, expected: 6},
+		{amount: 1, expected: 1},
+		{amount: -11, expected: -6},
+	}
+
+	for _, tc := range testCases {
+		converted, err := rate.Convert(tc.amount)
+		require.NoError(t, err)
+		require.Equal(t, tc.expected, converted)
+	}
+}
+
+func TestRateConvertMinorUnits(t *testing.T) {
+	provider, err := NewStaticRateProvider(map[string]string{
+		"USD/JPY": "150",
+		"USD/BHD": "0.376",
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	testCases := []struct {
+		name     string
+		from     string
+		to       string
+		amount   int64
+		expected int64
+	}{
+		// 12.34 USD is 1851 JPY, which has no minor unit
+		{name: "USDToJPY", from: "USD", to: "JPY", amount: 1234, expected: 1851},
+		// 10.00 USD is 3.760 BHD, which has three decimal places
+		{name: "USDToBHD", from: "USD", to: "BHD", amount: 1000, expected: 3760},
+		// 1851 JPY is 12.34 USD
+		{name: "JPYToUSD", from: "JPY", to: "USD", amount: 1851, expected: 1234},
+		// 3.760 BHD is 10.00 USD
+		{name: "BHDToUSD", from: "BHD", to: "USD", amount: 3760, expected: 1000},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			rate, err := provider.Rate(ctx, tc.from, tc.to)
+			require.NoError(t, err)
+
+			converted, err := rate.Convert(tc.amount)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, converted)
+		})
+	}
+}
+
+func TestRateConvertUnknownCurrency(t *testing.T) {
+	rate, err := NewRate("USD", "XXX", big.NewRat(1, 1))
+	require.NoError(t, err)
+
+	_, err = rate.Convert(100)
+	require.Error(t, err)
+}
@@ -0,0 +1,60 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/simplebank/pb"
+	"github.com/simplebank/service"
+)
+
+func (s *Server) CreateAccount(ctx context.Context, req *pb.CreateAccountRequest) (*pb.CreateAccountResponse, error) {
+	account, err := s.bank.CreateAccount(ctx, currentActor(ctx), req.GetCurrency())
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &pb.CreateAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (s *Server) GetAccount(ctx context.Context, req *pb.GetAccountRequest) (*pb.GetAccountResponse, error) {
+	if err := validateField("id", req.GetId(), "required,min=1"); err != nil {
+		return nil, err
+	}
+
+	account, err := s.bank.GetAccount(ctx, currentActor(ctx), req.GetId())
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &pb.GetAccountResponse{Account: convertAccount(account)}, nil
+}
+
+func (s *Server) ListAccounts(ctx context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	afterID, err := pagePosition(req.GetCursor(), 0)
+	if err != nil {
+		return nil, err
+	}
+	pageSize, err := pageLimit(req.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+
+	accounts, err := s.bank.ListAccounts(ctx, currentActor(ctx), service.ListAccountsParams{
+		AfterID: afterID,
+		// one extra row tells us whether there is another page
+		Limit: pageSize + 1,
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	rsp := &pb.ListAccountsResponse{}
+	if len(accounts) > int(pageSize) {
+		accounts = accounts[:pageSize]
+		rsp.NextCursor = encodeCursor(accounts[pageSize-1].ID)
+	}
+	for _, account := range accounts {
+		rsp.Accounts = append(rsp.Accounts, convertAccount(account))
+	}
+	return rsp, nil
+}
@@ -0,0 +1,72 @@
+package gapi
+
+import (
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/simplebank/pb"
+	"github.com/simplebank/repo"
+)
+
+func convertUser(user repo.User) *pb.User {
+	return &pb.User{
+		Username:          user.Username,
+		FullName:          user.FullName,
+		Email:             user.Email,
+		PendingEmail:      user.PendingEmail.String,
+		IsEmailVerified:   user.IsEmailVerified,
+		Role:              user.Role,
+		PasswordChangedAt: timestamppb.New(user.PasswordChangedAt),
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+	}
+}
+
+func convertAccount(account repo.Account) *pb.Account {
+	return &pb.Account{
+		Id:             account.ID,
+		Owner:          account.Owner,
+		Balance:        account.Balance,
+		Currency:       account.Currency,
+		OverdraftLimit: account.OverdraftLimit,
+		Status:         account.Status,
+		CreatedAt:      timestamppb.New(account.CreatedAt),
+	}
+}
+
+func convertTransfer(transfer repo.Transfer) *pb.Transfer {
+	return &pb.Transfer{
+		Id:              transfer.ID,
+		FromAccountId:   transfer.FromAccountID,
+		ToAccountId:     transfer.ToAccountID,
+		Amount:          transfer.Amount,
+		ToAmount:        transfer.ToAmount,
+		ExchangeRate:    transfer.ExchangeRate,
+		CreatedAt:       timestamppb.New(transfer.CreatedAt),
+		Description:     transfer.Description.String,
+		ClientReference: transfer.ClientReference.String,
+		Metadata:        transfer.MetadataMap(),
+		Currency:        transfer.Currency,
+		ToCurrency:      transfer.ToCurrency,
+		ReversalOf:      transfer.ReversalOf.Int64,
+	}
+}
+
+// convertEntry takes the account's currency because entries don't record it
+func convertEntry(entry repo.Entry, currency string) *pb.Entry {
+	return &pb.Entry{
+		Id:        entry.ID,
+		AccountId: entry.AccountID,
+		Amount:    entry.Amount,
+		CreatedAt: timestamppb.New(entry.CreatedAt),
+		Currency:  currency,
+	}
+}
+
+func convertTransferResult(result repo.TransferTxResult) *pb.TransferResult {
+	return &pb.TransferResult{
+		Transfer:    convertTransfer(result.Transfer),
+		FromAccount: convertAccount(result.FromAccount),
+		ToAccount:   convertAccount(result.ToAccount),
+		FromEntry:   convertEntry(result.FromEntry, result.FromAccount.Currency),
+		ToEntry:     convertEntry(result.ToEntry, result.ToAccount.Currency),
+	}
+}
@@ -0,0 +1,45 @@
+package gapi
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/simplebank/apperr"
+)
+
+// serviceError maps errors returned by the service layer to gRPC statuses through their apperr code,
+// internal errors are logged and hidden the same way the http respondError does
+func serviceError(err error) error {
+	code := apperr.CodeOf(err)
+	if code == apperr.CodeInternal {
+		log.Err(err).Msg("request failed")
+		return status.Error(codes.Internal, "the server could not complete the request")
+	}
+	return status.Error(grpcCode(code), err.Error())
+}
+
+// grpcCode picks the gRPC code matching the http status an apperr code is reported with
+func grpcCode(code apperr.Code) codes.Code {
+	switch code.HTTPStatus() {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		if code == apperr.CodeUserAlreadyExists || code == apperr.CodeEmailAlreadyInUse {
+			return codes.AlreadyExists
+		}
+		return codes.FailedPrecondition
+	case http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	default:
+		return codes.Internal
+	}
+}
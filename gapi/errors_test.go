@@ -0,0 +1,58 @@
+package gapi
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/simplebank/service"
+)
+
+func TestServiceError(t *testing.T) {
+	testCases := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{
+			name:    "NotFound",
+			err:     service.ErrAccountNotFound,
+			code:    codes.NotFound,
+			message: service.ErrAccountNotFound.Error(),
+		},
+		{
+			name:    "NotOwned",
+			err:     service.ErrAccountNotOwned,
+			code:    codes.PermissionDenied,
+			message: service.ErrAccountNotOwned.Error(),
+		},
+		{
+			name:    "InsufficientFunds",
+			err:     fmt.Errorf("transfer failed: %w", service.ErrInsufficientFunds),
+			code:    codes.FailedPrecondition,
+			message: "transfer failed: insufficient funds",
+		},
+		{
+			name:    "Internal",
+			err:     sql.ErrConnDone,
+			code:    codes.Internal,
+			message: "the server could not complete the request",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			st, ok := status.FromError(serviceError(tc.err))
+			require.True(t, ok)
+			require.Equal(t, tc.code, st.Code())
+			require.Equal(t, tc.message, st.Message())
+		})
+	}
+}
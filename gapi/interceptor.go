@@ -0,0 +1,122 @@
+package gapi
+
+import (
+	"context"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/pb"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+	"github.com/simplebank/token"
+)
+
+const (
+	authorizationHeaderKey  = "authorization"
+	authorizationTypeBearer = "bearer"
+)
+
+type contextKey int
+
+const (
+	authorizationPayloadKey contextKey = iota
+	authorizationUserKey
+)
+
+// publicMethods can be called without an access token
+var publicMethods = map[string]bool{
+	pb.SimpleBank_CreateUser_FullMethodName:       true,
+	pb.SimpleBank_LoginUser_FullMethodName:        true,
+	pb.SimpleBank_RenewAccessToken_FullMethodName: true,
+}
+
+// authInterceptor is the gRPC counterpart of the http authMiddleware,
+// every method outside publicMethods needs a bearer access token in the request metadata
+func authInterceptor(tokenMaker token.Maker, denylist token.Denylist, store repo.Store) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if publicMethods[info.FullMethod] {
+			return handler(ctx, req)
+		}
+
+		payload, user, err := authorize(ctx, tokenMaker, denylist, store)
+		if err != nil {
+			return nil, serviceError(err)
+		}
+
+		ctx = context.WithValue(ctx, authorizationPayloadKey, payload)
+		ctx = context.WithValue(ctx, authorizationUserKey, user)
+		return handler(ctx, req)
+	}
+}
+
+func authorize(ctx context.Context, tokenMaker token.Maker, denylist token.Denylist, store repo.Store) (*token.Payload, repo.User, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return nil, repo.User{}, apperr.New(apperr.CodeUnauthenticated, "missing metadata")
+	}
+
+	values := md.Get(authorizationHeaderKey)
+	if len(values) == 0 {
+		return nil, repo.User{}, apperr.New(apperr.CodeUnauthenticated, "authorization header is not provided")
+	}
+
+	fields := strings.Fields(values[0])
+	if len(fields) < 2 {
+		return nil, repo.User{}, apperr.New(apperr.CodeUnauthenticated, "invalid authorization header format")
+	}
+
+	authorizationType := strings.ToLower(fields[0])
+	if authorizationType != authorizationTypeBearer {
+		return nil, repo.User{}, apperr.Newf(apperr.CodeUnauthenticated, "unsupported authorization type %s", authorizationType)
+	}
+
+	payload, err := tokenMaker.VerifyToken(fields[1])
+	if err != nil {
+		return nil, repo.User{}, service.TokenError(err)
+	}
+
+	revoked, err := denylist.IsRevoked(ctx, payload.ID)
+	if err != nil {
+		return nil, repo.User{}, err
+	}
+	if revoked {
+		return nil, repo.User{}, service.ErrRevokedToken
+	}
+
+	user, err := store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return nil, repo.User{}, apperr.New(apperr.CodeUnauthenticated, "user no longer exists")
+		}
+		return nil, repo.User{}, err
+	}
+	if payload.IssuedAt.Before(user.PasswordChangedAt) || payload.Role != user.Role {
+		return nil, repo.User{}, service.ErrRevokedToken
+	}
+
+	return payload, user, nil
+}
+
+// authorizationPayload returns the access token payload stored by authInterceptor
+func authorizationPayload(ctx context.Context) *token.Payload {
+	return ctx.Value(authorizationPayloadKey).(*token.Payload)
+}
+
+// authorizationUser returns the authenticated user stored by authInterceptor
+func authorizationUser(ctx context.Context) repo.User {
+	return ctx.Value(authorizationUserKey).(repo.User)
+}
+
+// currentActor describes the authenticated user to the service layer
+func currentActor(ctx context.Context) service.Actor {
+	authPayload := authorizationPayload(ctx)
+	return service.Actor{
+		Username:      authPayload.Username,
+		Role:          authPayload.Role,
+		EmailVerified: authorizationUser(ctx).IsEmailVerified,
+	}
+}
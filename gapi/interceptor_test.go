@@ -0,0 +1,211 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/simplebank/pb"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name       string
+		method     string
+		setupAuth  func(t *testing.T, server *Server) context.Context
+		buildStubs func(store *mockdb.MockStore)
+		code       codes.Code
+	}{
+		{
+			name:   "OK",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+			},
+			code: codes.OK,
+		},
+		{
+			name:   "PublicMethod",
+			method: pb.SimpleBank_LoginUser_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				return context.Background()
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.OK,
+		},
+		{
+			name:   "NoAuthorization",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				return metadata.NewIncomingContext(context.Background(), metadata.MD{})
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "UnsupportedAuthorization",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				md := metadata.Pairs(authorizationHeaderKey, "basic dXNlcjpwYXNzd29yZA==")
+				return metadata.NewIncomingContext(context.Background(), md)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "InvalidAuthorizationFormat",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				md := metadata.Pairs(authorizationHeaderKey, "bearer")
+				return metadata.NewIncomingContext(context.Background(), md)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "ExpiredToken",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, user.Username, token.RoleCustomer, -time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "PasswordChanged",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.PasswordChangedAt = time.Now().Add(time.Minute)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(changed, nil)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "RoleChanged",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				changed := user
+				changed.Role = token.RoleTeller
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(changed, nil)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "UserNotFound",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(repo.User{}, repo.ErrRecordNotFound)
+			},
+			code: codes.Unauthenticated,
+		},
+		{
+			name:   "InternalError",
+			method: pb.SimpleBank_GetAccount_FullMethodName,
+			setupAuth: func(t *testing.T, server *Server) context.Context {
+				return newContextWithBearerToken(t, server.tokenMaker, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(repo.User{}, sql.ErrConnDone)
+			},
+			code: codes.Internal,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			interceptor := authInterceptor(server.tokenMaker, server.denylist, server.store)
+
+			called := false
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				called = true
+				if !publicMethods[tc.method] {
+					require.Equal(t, user.Username, authorizationPayload(ctx).Username)
+					require.Equal(t, user, authorizationUser(ctx))
+				}
+				return req, nil
+			}
+
+			info := &grpc.UnaryServerInfo{FullMethod: tc.method}
+			_, err := interceptor(tc.setupAuth(t, server), nil, info, handler)
+			if tc.code == codes.OK {
+				require.NoError(t, err)
+				require.True(t, called)
+				return
+			}
+			requireStatusCode(t, err, tc.code)
+			require.NotContains(t, status.Convert(err).Message(), sql.ErrConnDone.Error())
+			require.False(t, called)
+		})
+	}
+}
+
+func TestAuthInterceptorRevokedToken(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+
+	server := newTestServer(t, store)
+
+	accessToken, payload, err := server.tokenMaker.CreateToken("user", token.RoleCustomer, time.Minute)
+	require.NoError(t, err)
+	err = server.denylist.Revoke(context.Background(), payload.ID, payload.ExpiredAt)
+	require.NoError(t, err)
+
+	md := metadata.Pairs(authorizationHeaderKey, authorizationTypeBearer+" "+accessToken)
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	interceptor := authInterceptor(server.tokenMaker, server.denylist, server.store)
+	info := &grpc.UnaryServerInfo{FullMethod: pb.SimpleBank_GetAccount_FullMethodName}
+	_, err = interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("handler must not be called")
+		return nil, nil
+	})
+	requireStatusCode(t, err, codes.Unauthenticated)
+}
@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
+)
+
+func newTestServer(t *testing.T, store repo.Store) *Server {
+	appConfig, err := config.New()
+	require.NoError(t, err)
+
+	server, err := NewServer(appConfig, store, token.NewMemoryDenylist())
+	require.NoError(t, err)
+
+	return server
+}
+
+// newContextWithBearerToken builds the incoming metadata a client sends with an access token
+func newContextWithBearerToken(t *testing.T, tokenMaker token.Maker, username string, role string, duration time.Duration) context.Context {
+	accessToken, _, err := tokenMaker.CreateToken(username, role, duration)
+	require.NoError(t, err)
+
+	md := metadata.MD{
+		authorizationHeaderKey: []string{fmt.Sprintf("%s %s", authorizationTypeBearer, accessToken)},
+	}
+	return metadata.NewIncomingContext(context.Background(), md)
+}
+
+// newAuthorizedContext builds the context authInterceptor hands to the rpc handlers
+func newAuthorizedContext(t *testing.T, tokenMaker token.Maker, user repo.User) context.Context {
+	_, payload, err := tokenMaker.CreateToken(user.Username, user.Role, time.Minute)
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), authorizationPayloadKey, payload)
+	return context.WithValue(ctx, authorizationUserKey, user)
+}
+
+func requireStatusCode(t *testing.T, err error, code codes.Code) {
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	require.Equal(t, code, st.Code(), st.Message())
+}
+
+func randomUser(t *testing.T) (user repo.User, password string) {
+	password = testutils.RandomString(6)
+	hashedPassword, err := testutils.HashPassword(password)
+	require.NoError(t, err)
+
+	user = repo.User{
+		Username:        testutils.RandomOwner(),
+		HashedPassword:  hashedPassword,
+		FullName:        testutils.RandomOwner(),
+		Email:           testutils.RandomEmail(),
+		IsEmailVerified: true,
+		Role:            token.RoleCustomer,
+	}
+	return
+}
+
+func randomAccount(owner string) repo.Account {
+	return repo.Account{
+		ID:       testutils.RandomInt(1, 1000),
+		Owner:    owner,
+		Balance:  testutils.RandomMoney(),
+		Currency: testutils.RandomCurrency(),
+		Status:   repo.AccountStatusActive,
+	}
+}
@@ -0,0 +1,41 @@
+package gapi
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+const (
+	userAgentHeader     = "user-agent"
+	xForwardedForHeader = "x-forwarded-for"
+)
+
+// requestMetadata describes the client that sent a request, as recorded on sessions
+type requestMetadata struct {
+	UserAgent string
+	ClientIP  string
+}
+
+func extractMetadata(ctx context.Context) requestMetadata {
+	var mtdt requestMetadata
+
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if userAgents := md.Get(userAgentHeader); len(userAgents) > 0 {
+			mtdt.UserAgent = userAgents[0]
+		}
+		// set by a proxy in front of the server, the peer address would be the proxy itself
+		if clientIPs := md.Get(xForwardedForHeader); len(clientIPs) > 0 {
+			mtdt.ClientIP = clientIPs[0]
+		}
+	}
+
+	if len(mtdt.ClientIP) == 0 {
+		if p, ok := peer.FromContext(ctx); ok {
+			mtdt.ClientIP = p.Addr.String()
+		}
+	}
+
+	return mtdt
+}
@@ -0,0 +1,51 @@
+package gapi
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 50
+)
+
+var errInvalidCursor = status.Error(codes.InvalidArgument, "invalid cursor")
+
+// pageLimit validates the requested page size, falling back to defaultPageSize
+func pageLimit(pageSize int32) (int32, error) {
+	if pageSize == 0 {
+		return defaultPageSize, nil
+	}
+	if pageSize < 1 || pageSize > maxPageSize {
+		return 0, status.Errorf(codes.InvalidArgument, "page_size must be between 1 and %d", maxPageSize)
+	}
+	return pageSize, nil
+}
+
+// pagePosition decodes the cursor into the id of the last row already seen,
+// fallback is returned when the client asks for the first page
+func pagePosition(cursor string, fallback int64) (int64, error) {
+	if len(cursor) == 0 {
+		return fallback, nil
+	}
+
+	// cursors are shared with the http api
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errInvalidCursor
+	}
+	return id, nil
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
@@ -0,0 +1,80 @@
+package gapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/currency"
+	"github.com/simplebank/exchange"
+	"github.com/simplebank/pb"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+	"github.com/simplebank/token"
+)
+
+// Server serves gRPC requests for the banking service
+type Server struct {
+	pb.UnimplementedSimpleBankServer
+	appConfig  *config.Config
+	store      repo.Store
+	tokenMaker token.Maker
+	denylist   token.Denylist
+	bank       *service.Bank
+}
+
+// NewServer creates a new gRPC server
+func NewServer(appConfig *config.Config, store repo.Store, denylist token.Denylist) (*Server, error) {
+	tokenMaker, err := token.NewPasetoMaker(appConfig.TokenSymmetricKey)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create token maker: %w", err)
+	}
+
+	fxRates, err := exchange.NewStaticRateProvider(appConfig.FXRates)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create exchange rate provider: %w", err)
+	}
+
+	currencies, err := currency.NewRegistry(appConfig.Currencies)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create currency registry: %w", err)
+	}
+
+	server := &Server{
+		appConfig:  appConfig,
+		store:      store,
+		tokenMaker: tokenMaker,
+		denylist:   denylist,
+		bank:       service.NewBank(store, fxRates, currencies),
+	}
+	return server, nil
+}
+
+// Serve serves the gRPC endpoint until ctx is cancelled
+func (s *Server) Serve(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%s", s.appConfig.GRPCPort))
+	if err != nil {
+		return fmt.Errorf("cannot create listener: %w", err)
+	}
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(authInterceptor(s.tokenMaker, s.denylist, s.store)))
+	pb.RegisterSimpleBankServer(grpcServer, s)
+	reflection.Register(grpcServer)
+
+	ch := make(chan error, 1)
+	go func() {
+		ch <- grpcServer.Serve(listener)
+		close(ch)
+	}()
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		grpcServer.GracefulStop()
+		return ctx.Err()
+	}
+}
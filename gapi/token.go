@@ -0,0 +1,120 @@
+package gapi
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/pb"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+)
+
+func (s *Server) RenewAccessToken(ctx context.Context, req *pb.RenewAccessTokenRequest) (*pb.RenewAccessTokenResponse, error) {
+	if err := validateField("refresh_token", req.GetRefreshToken(), "required"); err != nil {
+		return nil, err
+	}
+
+	refreshPayload, err := s.tokenMaker.VerifyToken(req.GetRefreshToken())
+	if err != nil {
+		return nil, serviceError(service.TokenError(err))
+	}
+
+	session, err := s.store.GetSession(ctx, refreshPayload.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return nil, serviceError(service.ErrSessionNotFound)
+		}
+		return nil, serviceError(err)
+	}
+
+	if session.IsBlocked {
+		return nil, serviceError(apperr.New(apperr.CodeSessionInvalid, "blocked session"))
+	}
+
+	if session.Username != refreshPayload.Username {
+		return nil, serviceError(apperr.New(apperr.CodeSessionInvalid, "incorrect session user"))
+	}
+
+	if session.RefreshToken != req.GetRefreshToken() {
+		return nil, serviceError(apperr.New(apperr.CodeSessionInvalid, "mismatched session token"))
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		return nil, serviceError(apperr.New(apperr.CodeSessionInvalid, "expired session"))
+	}
+
+	if session.RotatedAt.Valid {
+		return nil, s.blockSessionFamily(ctx, session)
+	}
+
+	user, err := s.store.GetUser(ctx, session.Username)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	refreshToken, newRefreshPayload, err := s.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		s.appConfig.RefreshTokenDuration,
+	)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	mtdt := extractMetadata(ctx)
+	newSession, err := s.store.RotateSessionTx(ctx, repo.RotateSessionTxParams{
+		ParentID: session.ID,
+		Session: repo.CreateSessionParams{
+			ID:           newRefreshPayload.ID,
+			Username:     session.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    mtdt.UserAgent,
+			ClientIp:     mtdt.ClientIP,
+			IsBlocked:    false,
+			ExpiresAt:    newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrSessionRotated) {
+			return nil, s.blockSessionFamily(ctx, session)
+		}
+		return nil, serviceError(err)
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		s.appConfig.AccessTokenDuration,
+	)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	rsp := &pb.RenewAccessTokenResponse{
+		SessionId:             newSession.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(newRefreshPayload.ExpiredAt),
+	}
+	return rsp, nil
+}
+
+// blockSessionFamily mirrors the http handler of the same name
+func (s *Server) blockSessionFamily(ctx context.Context, session repo.Session) error {
+	if _, err := s.store.BlockSessionFamily(ctx, session.FamilyID); err != nil {
+		return serviceError(err)
+	}
+
+	log.Warn().
+		Str("username", session.Username).
+		Str("family_id", session.FamilyID.String()).
+		Msg("refresh token reuse detected, session family blocked")
+
+	return serviceError(apperr.New(apperr.CodeSessionInvalid, "refresh token has already been used"))
+}
@@ -0,0 +1,146 @@
+package gapi
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/pb"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func TestRenewAccessToken(t *testing.T) {
+	user, _ := randomUser(t)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore, session repo.Session)
+		checkResponse func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error, session repo.Session, tokenMaker token.Maker)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore, session repo.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.RotateSessionTxParams) (repo.Session, error) {
+						require.Equal(t, session.ID, arg.ParentID)
+						require.Equal(t, session.Username, arg.Session.Username)
+						return repo.Session{ID: arg.Session.ID, Username: arg.Session.Username, FamilyID: session.FamilyID}, nil
+					})
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error, session repo.Session, tokenMaker token.Maker) {
+				require.NoError(t, err)
+				require.NotEmpty(t, rsp.GetAccessToken())
+				require.NotEqual(t, session.RefreshToken, rsp.GetRefreshToken())
+				require.NotEqual(t, session.ID.String(), rsp.GetSessionId())
+			},
+		},
+		{
+			name: "RoleChanged",
+			buildStubs: func(store *mockdb.MockStore, session repo.Session) {
+				teller := user
+				teller.Role = token.RoleTeller
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(teller, nil)
+				store.EXPECT().
+					RotateSessionTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.RotateSessionTxParams) (repo.Session, error) {
+						return repo.Session{ID: arg.Session.ID, Username: arg.Session.Username, FamilyID: session.FamilyID}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error, session repo.Session, tokenMaker token.Maker) {
+				require.NoError(t, err)
+
+				accessPayload, err := tokenMaker.VerifyToken(rsp.GetAccessToken())
+				require.NoError(t, err)
+				require.Equal(t, token.RoleTeller, accessPayload.Role)
+			},
+		},
+		{
+			name: "GetUserInternalError",
+			buildStubs: func(store *mockdb.MockStore, session repo.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(repo.User{}, sql.ErrConnDone)
+				store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error, session repo.Session, tokenMaker token.Maker) {
+				requireStatusCode(t, err, codes.Internal)
+				require.NotContains(t, status.Convert(err).Message(), sql.ErrConnDone.Error())
+			},
+		},
+		{
+			name: "ReusedRefreshToken",
+			buildStubs: func(store *mockdb.MockStore, session repo.Session) {
+				session.RotatedAt = null.TimeFrom(time.Now().Add(-time.Minute))
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().BlockSessionFamily(gomock.Any(), gomock.Eq(session.FamilyID)).Times(1).Return(int64(2), nil)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error, session repo.Session, tokenMaker token.Maker) {
+				requireStatusCode(t, err, codes.Unauthenticated)
+			},
+		},
+		{
+			name: "BlockedSession",
+			buildStubs: func(store *mockdb.MockStore, session repo.Session) {
+				session.IsBlocked = true
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(session, nil)
+				store.EXPECT().RotateSessionTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error, session repo.Session, tokenMaker token.Maker) {
+				requireStatusCode(t, err, codes.Unauthenticated)
+			},
+		},
+		{
+			name: "SessionNotFound",
+			buildStubs: func(store *mockdb.MockStore, session repo.Session) {
+				store.EXPECT().GetSession(gomock.Any(), gomock.Eq(session.ID)).Times(1).Return(repo.Session{}, repo.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.RenewAccessTokenResponse, err error, session repo.Session, tokenMaker token.Maker) {
+				requireStatusCode(t, err, codes.NotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			server := newTestServer(t, store)
+
+			refreshToken, refreshPayload, err := server.tokenMaker.CreateToken(user.Username, user.Role, time.Hour)
+			require.NoError(t, err)
+
+			session := repo.Session{
+				ID:           refreshPayload.ID,
+				Username:     user.Username,
+				RefreshToken: refreshToken,
+				ExpiresAt:    refreshPayload.ExpiredAt,
+				FamilyID:     uuid.New(),
+			}
+			tc.buildStubs(store, session)
+
+			rsp, err := server.RenewAccessToken(context.Background(), &pb.RenewAccessTokenRequest{RefreshToken: refreshToken})
+			tc.checkResponse(t, rsp, err, session, server.tokenMaker)
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/simplebank/pb"
+	"github.com/simplebank/service"
+)
+
+func (s *Server) CreateTransfer(ctx context.Context, req *pb.CreateTransferRequest) (*pb.CreateTransferResponse, error) {
+	if err := validateCreateTransferRequest(req); err != nil {
+		return nil, err
+	}
+
+	result, err := s.bank.Transfer(ctx, currentActor(ctx), service.TransferParams{
+		FromAccountID:   req.GetFromAccountId(),
+		ToAccountID:     req.GetToAccountId(),
+		Amount:          req.GetAmount(),
+		Currency:        req.GetCurrency(),
+		Description:     req.GetDescription(),
+		ClientReference: req.GetClientReference(),
+		Metadata:        req.GetMetadata(),
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	return &pb.CreateTransferResponse{Result: convertTransferResult(result)}, nil
+}
+
+func validateCreateTransferRequest(req *pb.CreateTransferRequest) error {
+	if err := validateField("from_account_id", req.GetFromAccountId(), "required,min=1"); err != nil {
+		return err
+	}
+	if err := validateField("to_account_id", req.GetToAccountId(), "required,min=1"); err != nil {
+		return err
+	}
+	if err := validateField("amount", req.GetAmount(), "required,gt=0"); err != nil {
+		return err
+	}
+	if err := validateField("description", req.GetDescription(), "max=255"); err != nil {
+		return err
+	}
+	if err := validateField("client_reference", req.GetClientReference(), "max=64"); err != nil {
+		return err
+	}
+	return validateField("metadata", req.GetMetadata(), "max=20,dive,keys,min=1,max=40,endkeys,max=500")
+}
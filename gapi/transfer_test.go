@@ -0,0 +1,325 @@
+package gapi
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/pb"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/worker"
+)
+
+func TestCreateTransfer(t *testing.T) {
+	amount := int64(100)
+
+	user1, _ := randomUser(t)
+	user2, _ := randomUser(t)
+
+	account1 := randomAccount(user1.Username)
+	account2 := randomAccount(user2.Username)
+	account1.ID, account1.Currency = 1, testutils.USD
+	account2.ID, account2.Currency = 2, testutils.USD
+
+	eurAccount := randomAccount(user2.Username)
+	eurAccount.ID, eurAccount.Currency = 3, testutils.EUR
+
+	testCases := []struct {
+		name          string
+		user          repo.User
+		req           *pb.CreateTransferRequest
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, rsp *pb.CreateTransferResponse, err error)
+	}{
+		{
+			name: "OK",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      testutils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						require.Equal(t, account1.ID, arg.FromAccountID)
+						require.Equal(t, account2.ID, arg.ToAccountID)
+						require.Equal(t, amount, arg.Amount)
+						require.Zero(t, arg.ToAmount)
+
+						result := repo.TransferTxResult{
+							Transfer: repo.Transfer{ID: 1, FromAccountID: account1.ID, ToAccountID: account2.ID, Amount: amount},
+						}
+						// the recipient is notified from inside the transaction
+						return result, arg.AfterTransfer(store, result)
+					})
+				store.EXPECT().
+					EnqueueJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.EnqueueJobParams) (repo.Job, error) {
+						require.Equal(t, worker.TaskSendTransferNotification, arg.Kind)
+						return repo.Job{}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, amount, rsp.GetResult().GetTransfer().GetAmount())
+			},
+		},
+		{
+			name: "Details",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId:   account1.ID,
+				ToAccountId:     account2.ID,
+				Amount:          amount,
+				Currency:        testutils.USD,
+				Description:     "rent",
+				ClientReference: "inv-42",
+				Metadata:        map[string]string{"invoice": "42"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						require.Equal(t, "rent", arg.Description)
+						require.Equal(t, "inv-42", arg.ClientReference)
+						require.JSONEq(t, `{"invoice":"42"}`, string(arg.Metadata))
+
+						return repo.TransferTxResult{
+							Transfer: repo.Transfer{
+								ID:              1,
+								Amount:          amount,
+								Description:     null.StringFrom(arg.Description),
+								ClientReference: null.StringFrom(arg.ClientReference),
+								Metadata:        arg.Metadata,
+							},
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				transfer := rsp.GetResult().GetTransfer()
+				require.Equal(t, "rent", transfer.GetDescription())
+				require.Equal(t, "inv-42", transfer.GetClientReference())
+				require.Equal(t, map[string]string{"invoice": "42"}, transfer.GetMetadata())
+			},
+		},
+		{
+			name: "ExchangeRate",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   eurAccount.ID,
+				Amount:        amount,
+				Currency:      testutils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).Times(1).Return(eurAccount, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						require.Equal(t, int64(92), arg.ToAmount)
+						require.Equal(t, "0.92000000", arg.ExchangeRate)
+						return repo.TransferTxResult{
+							Transfer: repo.Transfer{
+								ID:           1,
+								Amount:       amount,
+								Currency:     arg.Currency,
+								ToAmount:     arg.ToAmount,
+								ToCurrency:   arg.ToCurrency,
+								ExchangeRate: arg.ExchangeRate,
+							},
+							FromAccount: account1,
+							ToAccount:   eurAccount,
+							FromEntry:   repo.Entry{AccountID: account1.ID, Amount: -amount},
+							ToEntry:     repo.Entry{AccountID: eurAccount.ID, Amount: arg.ToAmount},
+						}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				require.NoError(t, err)
+				result := rsp.GetResult()
+				require.Equal(t, testutils.USD, result.GetTransfer().GetCurrency())
+				require.Equal(t, testutils.EUR, result.GetTransfer().GetToCurrency())
+				require.Zero(t, result.GetTransfer().GetReversalOf())
+				require.Equal(t, testutils.USD, result.GetFromEntry().GetCurrency())
+				require.Equal(t, testutils.EUR, result.GetToEntry().GetCurrency())
+			},
+		},
+		{
+			name: "UnverifiedEmail",
+			user: func() repo.User {
+				user := user1
+				user.IsEmailVerified = false
+				return user
+			}(),
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      testutils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.PermissionDenied)
+			},
+		},
+		{
+			name: "NotAccountOwner",
+			user: user2,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      testutils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.PermissionDenied)
+			},
+		},
+		{
+			name: "CurrencyMismatch",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      testutils.EUR,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "ToAccountNotFound",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      testutils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.NotFound)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      testutils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account1.ID)).Times(1).Return(account1, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account2.ID)).Times(1).Return(account2, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrInsufficientFunds)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.FailedPrecondition)
+			},
+		},
+		{
+			name: "NegativeAmount",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        -amount,
+				Currency:      testutils.USD,
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "DescriptionTooLong",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      testutils.USD,
+				Description:   testutils.RandomString(256),
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+		{
+			name: "EmptyMetadataKey",
+			user: user1,
+			req: &pb.CreateTransferRequest{
+				FromAccountId: account1.ID,
+				ToAccountId:   account2.ID,
+				Amount:        amount,
+				Currency:      testutils.USD,
+				Metadata:      map[string]string{"": "42"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, rsp *pb.CreateTransferResponse, err error) {
+				requireStatusCode(t, err, codes.InvalidArgument)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			server := newTestServer(t, store)
+			ctx := newAuthorizedContext(t, server.tokenMaker, tc.user)
+			rsp, err := server.CreateTransfer(ctx, tc.req)
+			tc.checkResponse(t, rsp, err)
+		})
+	}
+}
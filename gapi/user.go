@@ -0,0 +1,128 @@
+package gapi
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/pb"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+	"github.com/simplebank/worker"
+)
+
+func (s *Server) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.CreateUserResponse, error) {
+	if err := validateCreateUserRequest(req); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := testutils.HashPassword(req.GetPassword())
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	arg := repo.CreateUserTxParams{
+		CreateUserParams: repo.CreateUserParams{
+			Username:       req.GetUsername(),
+			HashedPassword: hashedPassword,
+			FullName:       req.GetFullName(),
+			Email:          req.GetEmail(),
+		},
+		AfterCreate: func(q repo.Querier, user repo.User) error {
+			return worker.EnqueueSendVerifyEmail(ctx, q, worker.PayloadSendVerifyEmail{
+				Username: user.Username,
+				Email:    user.Email,
+			})
+		},
+	}
+
+	user, err := s.store.CreateUserTx(ctx, arg)
+	if err != nil {
+		if repo.ErrorCode(err) == repo.UniqueViolation {
+			return nil, serviceError(service.ErrUserAlreadyExists)
+		}
+		return nil, serviceError(err)
+	}
+
+	return &pb.CreateUserResponse{User: convertUser(user)}, nil
+}
+
+func validateCreateUserRequest(req *pb.CreateUserRequest) error {
+	if err := validateField("username", req.GetUsername(), "required,alphanum"); err != nil {
+		return err
+	}
+	if err := validateField("password", req.GetPassword(), "required,min=6"); err != nil {
+		return err
+	}
+	if err := validateField("full_name", req.GetFullName(), "required"); err != nil {
+		return err
+	}
+	return validateField("email", req.GetEmail(), "required,email")
+}
+
+func (s *Server) LoginUser(ctx context.Context, req *pb.LoginUserRequest) (*pb.LoginUserResponse, error) {
+	if err := validateField("username", req.GetUsername(), "required,alphanum"); err != nil {
+		return nil, err
+	}
+	if err := validateField("password", req.GetPassword(), "required,min=6"); err != nil {
+		return nil, err
+	}
+
+	user, err := s.store.GetUser(ctx, req.GetUsername())
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return nil, serviceError(service.ErrInvalidCredentials)
+		}
+		return nil, serviceError(err)
+	}
+
+	err = testutils.CheckPassword(req.GetPassword(), user.HashedPassword)
+	if err != nil {
+		return nil, serviceError(service.ErrInvalidCredentials)
+	}
+
+	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		s.appConfig.AccessTokenDuration,
+	)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		s.appConfig.RefreshTokenDuration,
+	)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	mtdt := extractMetadata(ctx)
+	session, err := s.store.CreateSession(ctx, repo.CreateSessionParams{
+		ID:           refreshPayload.ID,
+		Username:     user.Username,
+		RefreshToken: refreshToken,
+		UserAgent:    mtdt.UserAgent,
+		ClientIp:     mtdt.ClientIP,
+		IsBlocked:    false,
+		ExpiresAt:    refreshPayload.ExpiredAt,
+		FamilyID:     refreshPayload.ID,
+	})
+	if err != nil {
+		return nil, serviceError(err)
+	}
+
+	rsp := &pb.LoginUserResponse{
+		SessionId:             session.ID.String(),
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  timestamppb.New(accessPayload.ExpiredAt),
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: timestamppb.New(refreshPayload.ExpiredAt),
+		User:                  convertUser(user),
+	}
+	return rsp, nil
+}
@@ -0,0 +1,25 @@
+package gapi
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validate applies the same binding rules as the http request structs
+var validate = validator.New()
+
+// validateField checks value against validator tags, reporting failures as InvalidArgument
+func validateField(field string, value interface{}, tag string) error {
+	err := validate.Var(value, tag)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return status.Errorf(codes.InvalidArgument, "%s failed on the '%s' tag", field, validationErrs[0].Tag())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
@@ -1,17 +1,8 @@
 package testutils
 
-// Constants for all supported currencies
+// Constants for the currencies enabled by the default config
 const (
 	USD = "USD"
 	EUR = "EUR"
 	CAD = "CAD"
 )
-
-// IsSupportedCurrency returns true if the currency is supported
-func IsSupportedCurrency(currency string) bool {
-	switch currency {
-	case USD, EUR, CAD:
-		return true
-	}
-	return false
-}
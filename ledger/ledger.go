@@ -0,0 +1,99 @@
+// Package ledger checks that account balances and transfers reconcile with the entries they were posted as
+package ledger
+
+import (
+	"context"
+
+	"github.com/simplebank/money"
+	"github.com/simplebank/repo"
+)
+
+// DefaultPageSize is how many accounts Verify reads per query
+const DefaultPageSize = 1000
+
+// AccountDrift is an account whose stored balance differs from the sum of its entries
+type AccountDrift struct {
+	AccountID      int64
+	Balance        money.Amount
+	EntriesBalance money.Amount
+	// Drift is Balance minus EntriesBalance, positive when the account holds money no entry accounts for
+	Drift money.Amount
+}
+
+// UnbalancedTransfer is a transfer whose entries don't match its amounts, so the money it moved doesn't net to zero
+type UnbalancedTransfer struct {
+	TransferID int64
+	EntryCount int64
+	// Amount and Debited should be equal, as should ToAmount and Credited
+	Amount   money.Amount
+	Debited  money.Amount
+	ToAmount money.Amount
+	Credited money.Amount
+}
+
+// Report is the outcome of Verify
+type Report struct {
+	AccountsChecked     int
+	Drifts              []AccountDrift
+	UnbalancedTransfers []UnbalancedTransfer
+}
+
+// Reconciles returns true if no drift or unbalanced transfer was found
+func (r Report) Reconciles() bool {
+	return len(r.Drifts) == 0 && len(r.UnbalancedTransfers) == 0
+}
+
+// Verify recomputes every account's balance from its entries and checks that each transfer has a debit
+// and a credit entry matching its amounts. Accounts are read in pages of pageSize, each page is a consistent
+// snapshot because balances and entries are always written in the same transaction.
+func Verify(ctx context.Context, q repo.Querier, pageSize int32) (Report, error) {
+	var report Report
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	var afterID int64
+	for {
+		balances, err := q.ListAccountLedgerBalances(ctx, repo.ListAccountLedgerBalancesParams{
+			AfterID:   afterID,
+			PageLimit: pageSize,
+		})
+		if err != nil {
+			return report, err
+		}
+
+		for _, balance := range balances {
+			if balance.Balance != balance.EntriesBalance {
+				report.Drifts = append(report.Drifts, AccountDrift{
+					AccountID:      balance.ID,
+					Balance:        money.New(balance.Balance, balance.Currency),
+					EntriesBalance: money.New(balance.EntriesBalance, balance.Currency),
+					Drift:          money.New(balance.Balance-balance.EntriesBalance, balance.Currency),
+				})
+			}
+		}
+		report.AccountsChecked += len(balances)
+
+		if len(balances) < int(pageSize) {
+			break
+		}
+		afterID = balances[len(balances)-1].ID
+	}
+
+	transfers, err := q.ListUnbalancedTransfers(ctx)
+	if err != nil {
+		return report, err
+	}
+	for _, transfer := range transfers {
+		report.UnbalancedTransfers = append(report.UnbalancedTransfers, UnbalancedTransfer{
+			TransferID: transfer.ID,
+			EntryCount: transfer.EntryCount,
+			Amount:     money.New(transfer.Amount, transfer.Currency),
+			Debited:    money.New(transfer.Debited, transfer.Currency),
+			ToAmount:   money.New(transfer.ToAmount, transfer.ToCurrency),
+			Credited:   money.New(transfer.Credited, transfer.ToCurrency),
+		})
+	}
+
+	return report, nil
+}
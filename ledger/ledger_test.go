@@ -0,0 +1,96 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/money"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+)
+
+func TestVerify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+
+	firstPage := []repo.ListAccountLedgerBalancesRow{
+		{ID: 1, Currency: testutils.USD, Balance: 100, EntriesBalance: 100},
+		{ID: 2, Currency: testutils.EUR, Balance: 250, EntriesBalance: 200},
+	}
+	secondPage := []repo.ListAccountLedgerBalancesRow{
+		{ID: 5, Currency: testutils.USD, Balance: 0, EntriesBalance: 0},
+	}
+
+	gomock.InOrder(
+		store.EXPECT().
+			ListAccountLedgerBalances(gomock.Any(), gomock.Eq(repo.ListAccountLedgerBalancesParams{AfterID: 0, PageLimit: 2})).
+			Times(1).
+			Return(firstPage, nil),
+		store.EXPECT().
+			ListAccountLedgerBalances(gomock.Any(), gomock.Eq(repo.ListAccountLedgerBalancesParams{AfterID: 2, PageLimit: 2})).
+			Times(1).
+			Return(secondPage, nil),
+	)
+
+	unbalanced := []repo.ListUnbalancedTransfersRow{
+		{ID: 7, Currency: testutils.USD, ToCurrency: testutils.USD, Amount: 10, ToAmount: 10, EntryCount: 1, Debited: 10},
+	}
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any()).Times(1).Return(unbalanced, nil)
+
+	report, err := Verify(context.Background(), store, 2)
+	require.NoError(t, err)
+	require.False(t, report.Reconciles())
+	require.Equal(t, 3, report.AccountsChecked)
+
+	require.Equal(t, []AccountDrift{{
+		AccountID:      2,
+		Balance:        money.New(250, testutils.EUR),
+		EntriesBalance: money.New(200, testutils.EUR),
+		Drift:          money.New(50, testutils.EUR),
+	}}, report.Drifts)
+
+	require.Equal(t, []UnbalancedTransfer{{
+		TransferID: 7,
+		EntryCount: 1,
+		Amount:     money.New(10, testutils.USD),
+		Debited:    money.New(10, testutils.USD),
+		ToAmount:   money.New(10, testutils.USD),
+		Credited:   money.New(0, testutils.USD),
+	}}, report.UnbalancedTransfers)
+}
+
+func TestVerifyReconciles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		ListAccountLedgerBalances(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return([]repo.ListAccountLedgerBalancesRow{{ID: 1, Currency: testutils.USD, Balance: 100, EntriesBalance: 100}}, nil)
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any()).Times(1).Return([]repo.ListUnbalancedTransfersRow{}, nil)
+
+	report, err := Verify(context.Background(), store, 0)
+	require.NoError(t, err)
+	require.True(t, report.Reconciles())
+	require.Equal(t, 1, report.AccountsChecked)
+}
+
+func TestVerifyError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().ListAccountLedgerBalances(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+	store.EXPECT().ListUnbalancedTransfers(gomock.Any()).Times(0)
+
+	_, err := Verify(context.Background(), store, 0)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+}
@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// FileMailer stands in for an SMTP server by writing every message as an .eml file into a directory,
+// it is meant for local development and tests
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer creates a new FileMailer writing into dir, which is created if it doesn't exist
+func NewFileMailer(dir string, from string) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, errors.Wrapf(err, "unable to create mail directory %s", dir)
+	}
+
+	return &FileMailer{
+		dir:  dir,
+		from: from,
+	}, nil
+}
+
+// Send writes the message into a new file in the mail directory
+func (mailer *FileMailer) Send(_ context.Context, msg Message) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", mailer.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Body)
+
+	// the timestamp keeps the files sorted in the order they were sent
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), uuid.NewString())
+	err := os.WriteFile(filepath.Join(mailer.dir, name), []byte(b.String()), 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "unable to write message to %s", msg.To)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package mail
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+
+	mailer, err := NewFileMailer(dir, "no-reply@simplebank.local")
+	require.NoError(t, err)
+
+	msg := Message{
+		To:      "user@example.com",
+		Subject: "Verify your email",
+		Body:    "token: abc",
+	}
+
+	err = mailer.Send(context.Background(), msg)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, ".eml", filepath.Ext(files[0].Name()))
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	require.Contains(t, string(data), "From: no-reply@simplebank.local\r\n")
+	require.Contains(t, string(data), "To: user@example.com\r\n")
+	require.Contains(t, string(data), "Subject: Verify your email\r\n")
+	require.Contains(t, string(data), "\r\n\r\ntoken: abc")
+
+	err = mailer.Send(context.Background(), msg)
+	require.NoError(t, err)
+
+	files, err = os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
@@ -0,0 +1,18 @@
+package mail
+
+import (
+	"context"
+)
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer is an interface for delivering emails to users
+type Mailer interface {
+	// Send delivers the message to its recipient
+	Send(ctx context.Context, msg Message) error
+}
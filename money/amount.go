@@ -0,0 +1,128 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/simplebank/currency"
+)
+
+var (
+	// ErrInvalidAmount is returned for amounts that aren't decimal numbers or don't fit in an int64 of minor units
+	ErrInvalidAmount = errors.New("invalid amount")
+
+	// ErrTooPrecise is returned for amounts with more decimal places than the currency's minor unit
+	ErrTooPrecise = errors.New("amount is more precise than the currency allows")
+
+	// ErrUnknownCurrency is returned for codes that aren't in the ISO 4217 table
+	ErrUnknownCurrency = errors.New("unknown currency")
+)
+
+// Amount is a quantity of money, held as an integer number of its currency's minor unit
+type Amount struct {
+	minor    int64
+	currency string
+}
+
+// New creates an amount of minor units, 1234 USD cents is 12.34 USD
+func New(minor int64, currencyCode string) Amount {
+	return Amount{minor: minor, currency: currencyCode}
+}
+
+// Parse reads a decimal amount in the major unit, such as "12.34", rejecting more decimal places
+// than the currency has. Trailing zeros past the minor unit are accepted.
+func Parse(value string, currencyCode string) (Amount, error) {
+	cur, ok := currency.Lookup(currencyCode)
+	if !ok {
+		return Amount{}, fmt.Errorf("%s: %w", currencyCode, ErrUnknownCurrency)
+	}
+
+	digits := value
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+
+	whole, fraction, hasFraction := strings.Cut(digits, ".")
+	if !isDigits(whole) || (hasFraction && !isDigits(fraction)) {
+		return Amount{}, fmt.Errorf("%w: %q is not a decimal number", ErrInvalidAmount, value)
+	}
+
+	if len(fraction) > cur.MinorUnits {
+		if strings.Trim(fraction[cur.MinorUnits:], "0") != "" {
+			return Amount{}, fmt.Errorf("%w: %s has %d decimal places", ErrTooPrecise, currencyCode, cur.MinorUnits)
+		}
+		fraction = fraction[:cur.MinorUnits]
+	}
+	fraction += strings.Repeat("0", cur.MinorUnits-len(fraction))
+
+	minor, err := strconv.ParseInt(sign+whole+fraction, 10, 64)
+	if err != nil {
+		return Amount{}, fmt.Errorf("%w: %q is out of range", ErrInvalidAmount, value)
+	}
+
+	return New(minor, currencyCode), nil
+}
+
+func isDigits(s string) bool {
+	if len(s) == 0 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor returns the amount in minor units
+func (a Amount) Minor() int64 {
+	return a.minor
+}
+
+// Currency returns the ISO 4217 code of the amount's currency
+func (a Amount) Currency() string {
+	return a.currency
+}
+
+// String formats the amount followed by its currency, such as "12.34 USD"
+func (a Amount) String() string {
+	return currency.FormatAmount(a.minor, a.currency)
+}
+
+type amountJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encodes the amount as a decimal string so clients never deal with minor units or floats
+func (a Amount) MarshalJSON() ([]byte, error) {
+	cur, ok := currency.Lookup(a.currency)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", a.currency, ErrUnknownCurrency)
+	}
+
+	return json.Marshal(amountJSON{
+		Amount:   cur.Format(a.minor),
+		Currency: a.currency,
+	})
+}
+
+// UnmarshalJSON decodes an amount encoded by MarshalJSON
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	var encoded amountJSON
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return err
+	}
+
+	parsed, err := Parse(encoded.Amount, encoded.Currency)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
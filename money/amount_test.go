@@ -0,0 +1,71 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		currency string
+		minor    int64
+		err      error
+	}{
+		{name: "Cents", value: "12.34", currency: "USD", minor: 1234},
+		{name: "Whole", value: "12", currency: "USD", minor: 1200},
+		{name: "OneDecimal", value: "12.3", currency: "USD", minor: 1230},
+		{name: "Negative", value: "-0.05", currency: "USD", minor: -5},
+		{name: "TrailingZeros", value: "12.3400", currency: "USD", minor: 1234},
+		{name: "NoMinorUnit", value: "1500", currency: "JPY", minor: 1500},
+		{name: "ThreeDecimals", value: "1.234", currency: "KWD", minor: 1234},
+		{name: "TooPrecise", value: "12.345", currency: "USD", err: ErrTooPrecise},
+		{name: "TooPreciseYen", value: "15.5", currency: "JPY", err: ErrTooPrecise},
+		{name: "NotANumber", value: "12,34", currency: "USD", err: ErrInvalidAmount},
+		{name: "Empty", value: "", currency: "USD", err: ErrInvalidAmount},
+		{name: "MissingWhole", value: ".5", currency: "USD", err: ErrInvalidAmount},
+		{name: "OutOfRange", value: "99999999999999999999", currency: "USD", err: ErrInvalidAmount},
+		{name: "UnknownCurrency", value: "1.00", currency: "XXX", err: ErrUnknownCurrency},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			amount, err := Parse(tc.value, tc.currency)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.minor, amount.Minor())
+			require.Equal(t, tc.currency, amount.Currency())
+		})
+	}
+}
+
+func TestAmountString(t *testing.T) {
+	require.Equal(t, "12.34 USD", New(1234, "USD").String())
+	require.Equal(t, "1500 JPY", New(1500, "JPY").String())
+	require.Equal(t, "1.234 KWD", New(1234, "KWD").String())
+}
+
+func TestAmountJSON(t *testing.T) {
+	amount := New(-1205, "EUR")
+
+	data, err := json.Marshal(amount)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"-12.05","currency":"EUR"}`, string(data))
+
+	var decoded Amount
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Equal(t, amount, decoded)
+
+	_, err = json.Marshal(New(100, "XXX"))
+	require.ErrorIs(t, err, ErrUnknownCurrency)
+
+	err = json.Unmarshal([]byte(`{"amount":"1.001","currency":"USD"}`), &decoded)
+	require.ErrorIs(t, err, ErrTooPrecise)
+}
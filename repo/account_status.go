@@ -0,0 +1,30 @@
+package repo
+
+// Account statuses, only active accounts can send or receive money
+const (
+	AccountStatusActive = "active"
+	AccountStatusFrozen = "frozen"
+	AccountStatusClosed = "closed"
+)
+
+// accountStatusTransitions lists the statuses each status can move to
+var accountStatusTransitions = map[string][]string{
+	AccountStatusActive: {AccountStatusFrozen, AccountStatusClosed},
+	AccountStatusFrozen: {AccountStatusActive, AccountStatusClosed},
+	AccountStatusClosed: {AccountStatusActive},
+}
+
+// CanTransitionAccountStatus returns true if an account in status from can be moved to status to
+func CanTransitionAccountStatus(from string, to string) bool {
+	for _, status := range accountStatusTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive returns true if the account can send and receive money
+func (a Account) IsActive() bool {
+	return a.Status == AccountStatusActive
+}
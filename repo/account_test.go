@@ -36,6 +36,7 @@ func createRandomAccount(t *testing.T) Account {
 	assert.Equal(t, arg.Owner, account.Owner)
 	assert.Equal(t, arg.Balance, account.Balance)
 	assert.Equal(t, arg.Currency, account.Currency)
+	assert.Equal(t, AccountStatusActive, account.Status)
 
 	assert.NotZero(t, account.ID)
 	assert.NotZero(t, account.CreatedAt)
@@ -138,3 +139,40 @@ func TestListAccounts(t *testing.T) {
 		require.Equal(t, lastAccount.Owner, account.Owner)
 	}
 }
+
+func TestListAccountsAfter(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	user := createRandomUser(t)
+	currencies := []string{testutils.USD, testutils.EUR, testutils.CAD}
+	created := make([]Account, len(currencies))
+	for i, currency := range currencies {
+		account, err := r.CreateAccount(ctx, CreateAccountParams{
+			Owner:    user.Username,
+			Currency: currency,
+		})
+		require.NoError(t, err)
+		created[i] = account
+	}
+
+	firstPage, err := r.ListAccountsAfter(ctx, ListAccountsAfterParams{
+		Owner:     user.Username,
+		AfterID:   0,
+		PageLimit: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, created[:2], firstPage)
+
+	secondPage, err := r.ListAccountsAfter(ctx, ListAccountsAfterParams{
+		Owner:     user.Username,
+		AfterID:   firstPage[1].ID,
+		PageLimit: 2,
+	})
+	require.NoError(t, err)
+	require.Equal(t, created[2:], secondPage)
+}
@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostgresDenylist keeps revoked tokens in the revoked_tokens table so they are shared by every server instance
+type PostgresDenylist struct {
+	querier Querier
+}
+
+// NewPostgresDenylist creates a new PostgresDenylist
+func NewPostgresDenylist(querier Querier) *PostgresDenylist {
+	return &PostgresDenylist{querier: querier}
+}
+
+// Revoke denies the token with the given payload id until it expires
+func (denylist *PostgresDenylist) Revoke(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error {
+	// rows of tokens that have expired on their own are no longer needed
+	if _, err := denylist.querier.DeleteExpiredRevokedTokens(ctx); err != nil {
+		return err
+	}
+
+	return denylist.querier.CreateRevokedToken(ctx, CreateRevokedTokenParams{
+		ID:        tokenID,
+		ExpiresAt: expiresAt,
+	})
+}
+
+// IsRevoked checks if the token with the given payload id has been revoked
+func (denylist *PostgresDenylist) IsRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error) {
+	return denylist.querier.IsTokenRevoked(ctx, tokenID)
+}
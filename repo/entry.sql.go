@@ -7,36 +7,52 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	null "gopkg.in/guregu/null.v4"
 )
 
 const createEntry = `-- name: CreateEntry :one
 INSERT INTO entries (
     account_id,
-    amount
+    amount,
+    transfer_id,
+    external_transfer_id
 ) VALUES (
-             $1, $2
-         ) RETURNING id, account_id, amount, created_at
+             $1, $2, $3, $4
+         ) RETURNING id, account_id, amount, created_at, transfer_id, external_transfer_id
 `
 
 type CreateEntryParams struct {
-	AccountID int64 `db:"account_id" json:"account_id"`
-	Amount    int64 `db:"amount" json:"amount"`
+	AccountID          int64         `db:"account_id" json:"account_id"`
+	Amount             int64         `db:"amount" json:"amount"`
+	TransferID         sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	ExternalTransferID sql.NullInt64 `db:"external_transfer_id" json:"external_transfer_id"`
 }
 
 func (q *Queries) CreateEntry(ctx context.Context, arg CreateEntryParams) (Entry, error) {
-	row := q.db.QueryRowContext(ctx, createEntry, arg.AccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createEntry,
+		arg.AccountID,
+		arg.Amount,
+		arg.TransferID,
+		arg.ExternalTransferID,
+	)
 	var i Entry
 	err := row.Scan(
 		&i.ID,
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.ExternalTransferID,
 	)
 	return i, err
 }
 
 const getEntry = `-- name: GetEntry :one
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, external_transfer_id FROM entries
 WHERE id = $1 LIMIT 1
 `
 
@@ -48,12 +64,97 @@ func (q *Queries) GetEntry(ctx context.Context, id int64) (Entry, error) {
 		&i.AccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.TransferID,
+		&i.ExternalTransferID,
 	)
 	return i, err
 }
 
+const listAccountStatement = `-- name: ListAccountStatement :many
+SELECT id, account_id, amount, created_at, running_balance, transfer_id, description, client_reference, metadata FROM (
+    SELECT e.id,
+           e.account_id,
+           e.amount,
+           e.created_at,
+           (a.balance - SUM(e.amount) OVER () + SUM(e.amount) OVER (ORDER BY e.id))::bigint AS running_balance,
+           e.transfer_id,
+           t.description,
+           t.client_reference,
+           COALESCE(t.metadata, '{}')::jsonb AS metadata
+    FROM entries e
+    JOIN accounts a ON a.id = e.account_id
+    LEFT JOIN transfers t ON t.id = e.transfer_id
+    WHERE e.account_id = $1
+) AS statement
+WHERE
+    ($2::timestamptz IS NULL OR created_at >= $2) AND
+    ($3::timestamptz IS NULL OR created_at < $3) AND
+    id > $4
+ORDER BY id
+    LIMIT $5
+`
+
+type ListAccountStatementParams struct {
+	AccountID int64     `db:"account_id" json:"account_id"`
+	FromTime  null.Time `db:"from_time" json:"from_time"`
+	ToTime    null.Time `db:"to_time" json:"to_time"`
+	AfterID   int64     `db:"after_id" json:"after_id"`
+	PageLimit int32     `db:"page_limit" json:"page_limit"`
+}
+
+type ListAccountStatementRow struct {
+	ID              int64           `db:"id" json:"id"`
+	AccountID       int64           `db:"account_id" json:"account_id"`
+	Amount          int64           `db:"amount" json:"amount"`
+	CreatedAt       time.Time       `db:"created_at" json:"created_at"`
+	RunningBalance  int64           `db:"running_balance" json:"running_balance"`
+	TransferID      sql.NullInt64   `db:"transfer_id" json:"transfer_id"`
+	Description     null.String     `db:"description" json:"description"`
+	ClientReference null.String     `db:"client_reference" json:"client_reference"`
+	Metadata        json.RawMessage `db:"metadata" json:"metadata"`
+}
+
+func (q *Queries) ListAccountStatement(ctx context.Context, arg ListAccountStatementParams) ([]ListAccountStatementRow, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountStatement,
+		arg.AccountID,
+		arg.FromTime,
+		arg.ToTime,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListAccountStatementRow{}
+	for rows.Next() {
+		var i ListAccountStatementRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.RunningBalance,
+			&i.TransferID,
+			&i.Description,
+			&i.ClientReference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listEntries = `-- name: ListEntries :many
-SELECT id, account_id, amount, created_at FROM entries
+SELECT id, account_id, amount, created_at, transfer_id, external_transfer_id FROM entries
 WHERE account_id = $1
 ORDER BY id
     LIMIT $2
@@ -80,6 +181,8 @@ func (q *Queries) ListEntries(ctx context.Context, arg ListEntriesParams) ([]Ent
 			&i.AccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.TransferID,
+			&i.ExternalTransferID,
 		); err != nil {
 			return nil, err
 		}
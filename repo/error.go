@@ -14,6 +14,30 @@ const (
 
 var ErrRecordNotFound = sql.ErrNoRows
 
+// ErrInsufficientFunds is returned when a debit would take an account below its overdraft limit
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
+// ErrAccountInactive is returned when a transfer touches a frozen or closed account
+var ErrAccountInactive = errors.New("account is not active")
+
+// ErrAccountNotEmpty is returned when closing an account whose balance isn't zero
+var ErrAccountNotEmpty = errors.New("account balance must be zero to close it")
+
+// ErrExternalTransferNotPending is returned when settling or failing a deposit or withdrawal that was settled or failed already
+var ErrExternalTransferNotPending = errors.New("external transfer is no longer pending")
+
+// ErrInvalidStatusTransition is returned when an account can't move from its current status to the requested one
+var ErrInvalidStatusTransition = errors.New("invalid account status transition")
+
+// ErrSessionRotated is returned when a refresh token that was already exchanged is presented again
+var ErrSessionRotated = errors.New("refresh token has already been rotated")
+
+// ErrInvalidResetToken is returned when a password reset token is unknown, expired or already used
+var ErrInvalidResetToken = errors.New("password reset token is invalid or has expired")
+
+// ErrInvalidVerifyEmail is returned when an email verification token is unknown, expired or already used
+var ErrInvalidVerifyEmail = errors.New("email verification token is invalid or has expired")
+
 var ErrUniqueViolation = &pgconn.PgError{
 	Code: UniqueViolation,
 }
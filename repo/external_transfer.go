@@ -0,0 +1,22 @@
+package repo
+
+// ClearingAccountOwner owns one clearing account per currency, deposits and withdrawals are posted against them
+const ClearingAccountOwner = "simplebank"
+
+// Kinds of external transfers
+const (
+	ExternalTransferDeposit    = "deposit"
+	ExternalTransferWithdrawal = "withdrawal"
+)
+
+// Statuses of external transfers, a pending deposit doesn't move any money until it is settled
+const (
+	ExternalTransferPending = "pending"
+	ExternalTransferSettled = "settled"
+	ExternalTransferFailed  = "failed"
+)
+
+// IsClearing returns true for the system accounts money enters and leaves the bank through
+func (a Account) IsClearing() bool {
+	return a.Owner == ClearingAccountOwner
+}
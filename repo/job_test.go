@@ -0,0 +1,231 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+)
+
+func createRandomJob(t *testing.T, q Querier, runAt time.Time) Job {
+	arg := EnqueueJobParams{
+		Kind:        "test",
+		Payload:     []byte(`{"id": 1}`),
+		MaxAttempts: 3,
+		RunAt:       runAt,
+	}
+
+	job, err := q.EnqueueJob(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Kind, job.Kind)
+	require.JSONEq(t, string(arg.Payload), string(job.Payload))
+	require.Equal(t, "pending", job.Status)
+	require.Zero(t, job.Attempts)
+	require.Equal(t, arg.MaxAttempts, job.MaxAttempts)
+	return job
+}
+
+// claimJobByID claims due jobs until it finds the given one, other tests share the table
+func claimJobByID(t *testing.T, q Querier, staleBefore time.Time, id int64) (Job, bool) {
+	for {
+		job, err := q.ClaimJob(context.Background(), staleBefore)
+		if errors.Is(err, ErrRecordNotFound) {
+			return Job{}, false
+		}
+		require.NoError(t, err)
+		if job.ID == id {
+			return job, true
+		}
+	}
+}
+
+func TestClaimJob(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	later := createRandomJob(t, r, time.Now().Add(time.Hour))
+	due := createRandomJob(t, r, time.Now())
+
+	claimed, ok := claimJobByID(t, r, time.Now().Add(-time.Minute), due.ID)
+	require.True(t, ok)
+	require.Equal(t, "running", claimed.Status)
+	require.Equal(t, int32(1), claimed.Attempts)
+	require.True(t, claimed.LockedAt.Valid)
+
+	// jobs that are not due yet are never handed out
+	_, ok = claimJobByID(t, r, time.Now().Add(-time.Minute), later.ID)
+	require.False(t, ok)
+
+	// a running job is only reclaimed once its lock is stale
+	_, ok = claimJobByID(t, r, time.Now().Add(time.Minute), due.ID)
+	require.True(t, ok)
+
+	reclaimed, err := r.GetJob(ctx, due.ID)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), reclaimed.Attempts)
+}
+
+func TestCompleteJob(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	job := createRandomJob(t, r, time.Now())
+	claimed, ok := claimJobByID(t, r, time.Now().Add(-time.Minute), job.ID)
+	require.True(t, ok)
+
+	rows, err := r.CompleteJob(ctx, CompleteJobParams{
+		ID:       job.ID,
+		Attempts: claimed.Attempts,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	done, err := r.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "done", done.Status)
+	require.False(t, done.LockedAt.Valid)
+	require.True(t, done.FinishedAt.Valid)
+}
+
+func TestCompleteJobReclaimed(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	job := createRandomJob(t, r, time.Now())
+	stale, ok := claimJobByID(t, r, time.Now().Add(-time.Minute), job.ID)
+	require.True(t, ok)
+
+	// the lock expired and another worker took the job over
+	reclaimed, ok := claimJobByID(t, r, time.Now().Add(time.Hour), job.ID)
+	require.True(t, ok)
+
+	rows, err := r.CompleteJob(ctx, CompleteJobParams{
+		ID:       job.ID,
+		Attempts: stale.Attempts,
+	})
+	require.NoError(t, err)
+	require.Zero(t, rows)
+
+	running, err := r.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "running", running.Status)
+	require.Equal(t, reclaimed.Attempts, running.Attempts)
+}
+
+func TestRetryJob(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	job := createRandomJob(t, r, time.Now())
+	claimed, ok := claimJobByID(t, r, time.Now().Add(-time.Minute), job.ID)
+	require.True(t, ok)
+
+	runAt := time.Now().Add(time.Hour)
+	rows, err := r.RetryJob(ctx, RetryJobParams{
+		RunAt:     runAt,
+		LastError: null.StringFrom("boom"),
+		ID:        job.ID,
+		Attempts:  claimed.Attempts,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	retried, err := r.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "pending", retried.Status)
+	require.Equal(t, null.StringFrom("boom"), retried.LastError)
+	require.WithinDuration(t, runAt, retried.RunAt, time.Second)
+	require.False(t, retried.LockedAt.Valid)
+
+	// not due again until the backoff has passed
+	_, ok = claimJobByID(t, r, time.Now().Add(-time.Minute), job.ID)
+	require.False(t, ok)
+}
+
+func TestKillJob(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	job := createRandomJob(t, r, time.Now())
+	claimed, ok := claimJobByID(t, r, time.Now().Add(-time.Minute), job.ID)
+	require.True(t, ok)
+
+	rows, err := r.KillJob(ctx, KillJobParams{
+		LastError: null.StringFrom("boom"),
+		ID:        job.ID,
+		Attempts:  claimed.Attempts,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1), rows)
+
+	dead, err := r.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "dead", dead.Status)
+	require.Equal(t, null.StringFrom("boom"), dead.LastError)
+	require.True(t, dead.FinishedAt.Valid)
+
+	_, ok = claimJobByID(t, r, time.Now().Add(time.Hour), job.ID)
+	require.False(t, ok)
+}
+
+func TestKillStaleJobs(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	job := createRandomJob(t, r, time.Now())
+	for i := int32(1); i <= job.MaxAttempts; i++ {
+		claimed, ok := claimJobByID(t, r, time.Now().Add(time.Hour), job.ID)
+		require.True(t, ok)
+		require.Equal(t, i, claimed.Attempts)
+	}
+
+	// a stale lock on the last attempt is not reclaimed
+	_, ok := claimJobByID(t, r, time.Now().Add(time.Hour), job.ID)
+	require.False(t, ok)
+
+	// the lock is not stale yet
+	_, err := r.KillStaleJobs(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+
+	running, err := r.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "running", running.Status)
+
+	rows, err := r.KillStaleJobs(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, rows, int64(1))
+
+	dead, err := r.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+	require.Equal(t, "dead", dead.Status)
+	require.True(t, dead.LastError.Valid)
+	require.False(t, dead.LockedAt.Valid)
+	require.True(t, dead.FinishedAt.Valid)
+}
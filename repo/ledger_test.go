@@ -0,0 +1,101 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAccountLedgerBalances(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	// random accounts are created with a balance no entry accounts for
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account1 = fundAccount(t, store, account1, 10)
+
+	_, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+	})
+	require.NoError(t, err)
+
+	balances, err := store.ListAccountLedgerBalances(ctx, ListAccountLedgerBalancesParams{
+		AfterID:   account1.ID - 1,
+		PageLimit: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+
+	require.Equal(t, account1.ID, balances[0].ID)
+	require.Equal(t, account1.Balance-10, balances[0].Balance)
+	require.Equal(t, int64(-10), balances[0].EntriesBalance)
+
+	require.Equal(t, account2.ID, balances[1].ID)
+	require.Equal(t, account2.Balance+10, balances[1].Balance)
+	require.Equal(t, int64(10), balances[1].EntriesBalance)
+}
+
+func TestListUnbalancedTransfers(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+	account1 = fundAccount(t, store, account1, 10)
+
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+	})
+	require.NoError(t, err)
+
+	// a transfer that only debited its source account
+	broken, err := store.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        5,
+		ToAmount:      5,
+		ExchangeRate:  "1",
+		Currency:      account1.Currency,
+		ToCurrency:    account1.Currency,
+		Metadata:      emptyMetadata,
+	})
+	require.NoError(t, err)
+	_, err = store.CreateEntry(ctx, CreateEntryParams{
+		AccountID:  account1.ID,
+		Amount:     -5,
+		TransferID: sql.NullInt64{Int64: broken.ID, Valid: true},
+	})
+	require.NoError(t, err)
+
+	transfers, err := store.ListUnbalancedTransfers(ctx)
+	require.NoError(t, err)
+
+	unbalanced := make(map[int64]ListUnbalancedTransfersRow)
+	for _, transfer := range transfers {
+		unbalanced[transfer.ID] = transfer
+	}
+	require.NotContains(t, unbalanced, result.Transfer.ID)
+	require.Contains(t, unbalanced, broken.ID)
+
+	row := unbalanced[broken.ID]
+	require.Equal(t, int64(1), row.EntryCount)
+	require.Equal(t, int64(5), row.Debited)
+	require.Zero(t, row.Credited)
+}
@@ -20,7 +20,7 @@ func SetupTables(t *testing.T) (*sql.DB, func()) {
 	r.NoError(err)
 
 	return db, func() {
-		_, err = db.Exec("TRUNCATE \"accounts\",\"entries\",\"transfers\"")
+		_, err = db.Exec("TRUNCATE \"accounts\",\"entries\",\"transfers\",\"external_transfers\",\"scheduled_transfers\",\"scheduled_transfer_runs\"")
 		r.NoError(err)
 
 		err = db.Close()
@@ -5,9 +5,12 @@
 package repo
 
 import (
+	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
+	null "gopkg.in/guregu/null.v4"
 )
 
 type Account struct {
@@ -16,6 +19,10 @@ type Account struct {
 	Balance   int64     `db:"balance" json:"balance"`
 	Currency  string    `db:"currency" json:"currency"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	// how far below zero the balance is allowed to go
+	OverdraftLimit int64 `db:"overdraft_limit" json:"overdraft_limit"`
+	// one of active, frozen or closed, only active accounts can send or receive money
+	Status string `db:"status" json:"status"`
 }
 
 type Entry struct {
@@ -24,6 +31,108 @@ type Entry struct {
 	// can be negative or positive
 	Amount    int64     `db:"amount" json:"amount"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	// transfer the entry is one side of, every transfer has a debit and a credit entry
+	TransferID sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	// deposit or withdrawal the entry is one side of
+	ExternalTransferID sql.NullInt64 `db:"external_transfer_id" json:"external_transfer_id"`
+}
+
+type ExternalTransfer struct {
+	ID int64 `db:"id" json:"id"`
+	// deposit credits the account from the clearing account, withdrawal debits it into the clearing account
+	Kind      string `db:"kind" json:"kind"`
+	AccountID int64  `db:"account_id" json:"account_id"`
+	// system account of the same currency the money comes from or goes to
+	ClearingAccountID int64  `db:"clearing_account_id" json:"clearing_account_id"`
+	Amount            int64  `db:"amount" json:"amount"`
+	Currency          string `db:"currency" json:"currency"`
+	// id of the operation in the system the money comes from or goes to, unique per kind
+	ExternalReference string `db:"external_reference" json:"external_reference"`
+	// pending until the external system confirms the operation, then settled, or failed when it is rejected
+	Status string `db:"status" json:"status"`
+	// staff member who recorded the operation
+	CreatedBy string    `db:"created_by" json:"created_by"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	SettledAt null.Time `db:"settled_at" json:"settled_at"`
+	// when the entries were posted, pending deposits are only posted once settled while withdrawals are posted right away
+	PostedAt null.Time `db:"posted_at" json:"posted_at"`
+	// when the operation was rejected, money already posted is given back by compensating entries
+	FailedAt null.Time `db:"failed_at" json:"failed_at"`
+}
+
+type IdempotencyKey struct {
+	Username    string `db:"username" json:"username"`
+	Key         string `db:"key" json:"key"`
+	RequestHash string `db:"request_hash" json:"request_hash"`
+	// 0 while the original request is still in flight
+	ResponseCode int32           `db:"response_code" json:"response_code"`
+	ResponseBody json.RawMessage `db:"response_body" json:"response_body"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+}
+
+type Job struct {
+	ID      int64           `db:"id" json:"id"`
+	Kind    string          `db:"kind" json:"kind"`
+	Payload json.RawMessage `db:"payload" json:"payload"`
+	// pending until claimed, running while a worker holds it, then done or dead once out of attempts
+	Status      string `db:"status" json:"status"`
+	Attempts    int32  `db:"attempts" json:"attempts"`
+	MaxAttempts int32  `db:"max_attempts" json:"max_attempts"`
+	// earliest time the job may be claimed, pushed back on every retry
+	RunAt time.Time `db:"run_at" json:"run_at"`
+	// when a worker claimed the job, running jobs locked for too long are reclaimed
+	LockedAt   null.Time   `db:"locked_at" json:"locked_at"`
+	LastError  null.String `db:"last_error" json:"last_error"`
+	FinishedAt null.Time   `db:"finished_at" json:"finished_at"`
+	CreatedAt  time.Time   `db:"created_at" json:"created_at"`
+}
+
+type PasswordResetToken struct {
+	ID       int64  `db:"id" json:"id"`
+	Username string `db:"username" json:"username"`
+	// sha256 of the token sent to the user, the token itself is never stored
+	TokenHash string    `db:"token_hash" json:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	// set when the token is redeemed, a token can only be used once
+	UsedAt    null.Time `db:"used_at" json:"used_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type RevokedToken struct {
+	// id of the token payload
+	ID uuid.UUID `db:"id" json:"id"`
+	// expiry of the token itself, the row is useless afterwards
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type ScheduledTransfer struct {
+	ID int64 `db:"id" json:"id"`
+	// user the transfers are made on behalf of, they must own the source account
+	Owner         string `db:"owner" json:"owner"`
+	FromAccountID int64  `db:"from_account_id" json:"from_account_id"`
+	ToAccountID   int64  `db:"to_account_id" json:"to_account_id"`
+	Amount        int64  `db:"amount" json:"amount"`
+	Currency      string `db:"currency" json:"currency"`
+	// standard cron expression or descriptor such as @monthly or @every 24h, NULL for a one-off transfer
+	Schedule null.String `db:"schedule" json:"schedule"`
+	// occurrence the scheduler executes next, NULL once completed or cancelled
+	NextRunAt null.Time `db:"next_run_at" json:"next_run_at"`
+	// active while occurrences are executed, paused to skip them, completed after a one-off ran, cancelled when deleted
+	Status    string    `db:"status" json:"status"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+type ScheduledTransferRun struct {
+	ID                  int64 `db:"id" json:"id"`
+	ScheduledTransferID int64 `db:"scheduled_transfer_id" json:"scheduled_transfer_id"`
+	// occurrence that was executed, unique per scheduled transfer so it never runs twice
+	ScheduledFor time.Time `db:"scheduled_for" json:"scheduled_for"`
+	// transfer made for the occurrence, NULL when it failed
+	TransferID sql.NullInt64 `db:"transfer_id" json:"transfer_id"`
+	// why the occurrence failed, NULL when it succeeded
+	Error     null.String `db:"error" json:"error"`
+	CreatedAt time.Time   `db:"created_at" json:"created_at"`
 }
 
 type Session struct {
@@ -35,6 +144,12 @@ type Session struct {
 	IsBlocked    bool      `db:"is_blocked" json:"is_blocked"`
 	ExpiresAt    time.Time `db:"expires_at" json:"expires_at"`
 	CreatedAt    time.Time `db:"created_at" json:"created_at"`
+	// id of the login session every rotated refresh token descends from
+	FamilyID uuid.UUID `db:"family_id" json:"family_id"`
+	// session whose refresh token was exchanged for this one
+	ParentID uuid.NullUUID `db:"parent_id" json:"parent_id"`
+	// set once the refresh token has been exchanged, presenting it again blocks the family
+	RotatedAt null.Time `db:"rotated_at" json:"rotated_at"`
 }
 
 type Transfer struct {
@@ -44,6 +159,22 @@ type Transfer struct {
 	// must be positive
 	Amount    int64     `db:"amount" json:"amount"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
+	// amount credited to the destination account, in its currency
+	ToAmount int64 `db:"to_amount" json:"to_amount"`
+	// rate applied to amount to get to_amount
+	ExchangeRate string `db:"exchange_rate" json:"exchange_rate"`
+	// currency of amount, the source account's currency
+	Currency string `db:"currency" json:"currency"`
+	// currency of to_amount, the destination account's currency
+	ToCurrency string `db:"to_currency" json:"to_currency"`
+	// transfer this one gives back, it moves the original amounts in the opposite direction
+	ReversalOf sql.NullInt64 `db:"reversal_of" json:"reversal_of"`
+	// memo shown to both sides of the transfer
+	Description null.String `db:"description" json:"description"`
+	// reference the sender attached to reconcile the transfer with their own records
+	ClientReference null.String `db:"client_reference" json:"client_reference"`
+	// free-form string keys and values the sender attached
+	Metadata json.RawMessage `db:"metadata" json:"metadata"`
 }
 
 type User struct {
@@ -53,4 +184,22 @@ type User struct {
 	Email             string    `db:"email" json:"email"`
 	PasswordChangedAt time.Time `db:"password_changed_at" json:"password_changed_at"`
 	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	// one of customer, teller or admin, embedded in issued tokens
+	Role string `db:"role" json:"role"`
+	// requested email address, replaces email once verified
+	PendingEmail null.String `db:"pending_email" json:"pending_email"`
+	// set once the user redeems a verify_emails token for their address
+	IsEmailVerified bool `db:"is_email_verified" json:"is_email_verified"`
+}
+
+type VerifyEmail struct {
+	ID       int64  `db:"id" json:"id"`
+	Username string `db:"username" json:"username"`
+	Email    string `db:"email" json:"email"`
+	// sha256 of the token sent to the user, the token itself is never stored
+	TokenHash string    `db:"token_hash" json:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at" json:"expires_at"`
+	// set when the token is redeemed, a token can only be used once
+	UsedAt    null.Time `db:"used_at" json:"used_at"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
@@ -0,0 +1,25 @@
+package repo
+
+import (
+	"github.com/simplebank/money"
+)
+
+// BalanceAmount returns the balance in the account's currency
+func (a Account) BalanceAmount() money.Amount {
+	return money.New(a.Balance, a.Currency)
+}
+
+// OverdraftLimitAmount returns the overdraft limit in the account's currency
+func (a Account) OverdraftLimitAmount() money.Amount {
+	return money.New(a.OverdraftLimit, a.Currency)
+}
+
+// SentAmount returns the amount taken from the source account
+func (t Transfer) SentAmount() money.Amount {
+	return money.New(t.Amount, t.Currency)
+}
+
+// ReceivedAmount returns the amount credited to the destination account
+func (t Transfer) ReceivedAmount() money.Amount {
+	return money.New(t.ToAmount, t.ToCurrency)
+}
@@ -1,9 +1,11 @@
 -- name: CreateEntry :one
 INSERT INTO entries (
     account_id,
-    amount
+    amount,
+    transfer_id,
+    external_transfer_id
 ) VALUES (
-             $1, $2
+             $1, $2, $3, $4
          ) RETURNING *;
 
 -- name: GetEntry :one
@@ -15,4 +17,27 @@ SELECT * FROM entries
 WHERE account_id = $1
 ORDER BY id
     LIMIT $2
-OFFSET $3;
\ No newline at end of file
+OFFSET $3;
+
+-- name: ListAccountStatement :many
+SELECT id, account_id, amount, created_at, running_balance, transfer_id, description, client_reference, metadata FROM (
+    SELECT e.id,
+           e.account_id,
+           e.amount,
+           e.created_at,
+           (a.balance - SUM(e.amount) OVER () + SUM(e.amount) OVER (ORDER BY e.id))::bigint AS running_balance,
+           e.transfer_id,
+           t.description,
+           t.client_reference,
+           COALESCE(t.metadata, '{}')::jsonb AS metadata
+    FROM entries e
+    JOIN accounts a ON a.id = e.account_id
+    LEFT JOIN transfers t ON t.id = e.transfer_id
+    WHERE e.account_id = sqlc.arg(account_id)
+) AS statement
+WHERE
+    (sqlc.narg(from_time)::timestamptz IS NULL OR created_at >= sqlc.narg(from_time)) AND
+    (sqlc.narg(to_time)::timestamptz IS NULL OR created_at < sqlc.narg(to_time)) AND
+    id > sqlc.arg(after_id)
+ORDER BY id
+    LIMIT sqlc.arg(page_limit);
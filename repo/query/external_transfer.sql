@@ -0,0 +1,36 @@
+-- name: CreateExternalTransfer :one
+INSERT INTO external_transfers (
+    kind,
+    account_id,
+    clearing_account_id,
+    amount,
+    currency,
+    external_reference,
+    status,
+    created_by,
+    settled_at,
+    posted_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+) RETURNING *;
+
+-- name: GetExternalTransfer :one
+SELECT * FROM external_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: GetExternalTransferForUpdate :one
+SELECT * FROM external_transfers
+WHERE id = $1 LIMIT 1
+FOR NO KEY UPDATE;
+
+-- name: SettleExternalTransfer :one
+UPDATE external_transfers
+SET status = 'settled', settled_at = now(), posted_at = COALESCE(posted_at, now())
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
+
+-- name: FailExternalTransfer :one
+UPDATE external_transfers
+SET status = 'failed', failed_at = now()
+WHERE id = $1 AND status = 'pending'
+RETURNING *;
@@ -0,0 +1,35 @@
+-- name: CreateIdempotencyKey :one
+INSERT INTO idempotency_keys (
+    username,
+    key,
+    request_hash
+) VALUES (
+             $1, $2, $3
+         ) RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE username = $1 AND key = $2 LIMIT 1;
+
+-- name: UpdateIdempotencyKeyResponse :one
+UPDATE idempotency_keys
+SET response_code = $3,
+    response_body = $4
+WHERE username = $1 AND key = $2
+    RETURNING *;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE username = $1 AND key = $2;
+
+-- name: ReclaimIdempotencyKey :one
+-- hands a key left in flight by a request that never finished to a retry of the same request,
+-- only one of several concurrent retries gets it
+UPDATE idempotency_keys
+SET created_at = now()
+WHERE username = sqlc.arg(username) AND
+      key = sqlc.arg(key) AND
+      request_hash = sqlc.arg(request_hash) AND
+      response_code = 0 AND
+      created_at < sqlc.arg(abandoned_before)
+    RETURNING *;
@@ -0,0 +1,68 @@
+-- name: EnqueueJob :one
+INSERT INTO jobs (
+    kind,
+    payload,
+    max_attempts,
+    run_at
+) VALUES (
+             $1, $2, $3, $4
+         ) RETURNING *;
+
+-- name: ClaimJob :one
+UPDATE jobs
+SET
+    status = 'running',
+    attempts = attempts + 1,
+    locked_at = now()
+WHERE id = (
+    SELECT id FROM jobs
+    WHERE run_at <= now() AND (
+            status = 'pending' OR
+            (status = 'running' AND locked_at < sqlc.arg(stale_before)::timestamptz AND attempts < max_attempts)
+        )
+    ORDER BY run_at
+    LIMIT 1
+    FOR UPDATE SKIP LOCKED
+)
+    RETURNING *;
+
+-- name: CompleteJob :execrows
+-- attempts identifies the claim, a worker whose lock was reclaimed no longer matches
+UPDATE jobs
+SET
+    status = 'done',
+    locked_at = NULL,
+    finished_at = now()
+WHERE id = sqlc.arg(id) AND status = 'running' AND attempts = sqlc.arg(attempts);
+
+-- name: RetryJob :execrows
+UPDATE jobs
+SET
+    status = 'pending',
+    locked_at = NULL,
+    run_at = sqlc.arg(run_at),
+    last_error = sqlc.arg(last_error)
+WHERE id = sqlc.arg(id) AND status = 'running' AND attempts = sqlc.arg(attempts);
+
+-- name: KillJob :execrows
+UPDATE jobs
+SET
+    status = 'dead',
+    locked_at = NULL,
+    last_error = sqlc.arg(last_error),
+    finished_at = now()
+WHERE id = sqlc.arg(id) AND status = 'running' AND attempts = sqlc.arg(attempts);
+
+-- name: KillStaleJobs :execrows
+-- a worker died holding these jobs on their last attempt, they won't be claimed again
+UPDATE jobs
+SET
+    status = 'dead',
+    locked_at = NULL,
+    last_error = 'worker stopped before the job finished',
+    finished_at = now()
+WHERE status = 'running' AND locked_at < sqlc.arg(stale_before)::timestamptz AND attempts >= max_attempts;
+
+-- name: GetJob :one
+SELECT * FROM jobs
+WHERE id = $1 LIMIT 1;
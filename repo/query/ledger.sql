@@ -0,0 +1,29 @@
+-- name: ListAccountLedgerBalances :many
+SELECT a.id,
+       a.currency,
+       a.balance,
+       COALESCE(SUM(e.amount), 0)::bigint AS entries_balance
+FROM accounts a
+LEFT JOIN entries e ON e.account_id = a.id
+WHERE a.id > sqlc.arg(after_id)
+GROUP BY a.id
+ORDER BY a.id
+    LIMIT sqlc.arg(page_limit);
+
+-- name: ListUnbalancedTransfers :many
+SELECT t.id,
+       t.currency,
+       t.to_currency,
+       t.amount,
+       t.to_amount,
+       COUNT(e.id) AS entry_count,
+       COALESCE(-SUM(e.amount) FILTER (WHERE e.amount < 0), 0)::bigint AS debited,
+       COALESCE(SUM(e.amount) FILTER (WHERE e.amount > 0), 0)::bigint AS credited
+FROM transfers t
+LEFT JOIN entries e ON e.transfer_id = t.id
+GROUP BY t.id
+HAVING COUNT(e.id) <> 2 OR
+       COALESCE(-SUM(e.amount) FILTER (WHERE e.amount < 0), 0) <> t.amount OR
+       COALESCE(SUM(e.amount) FILTER (WHERE e.amount > 0), 0) <> t.to_amount OR
+       (t.currency = t.to_currency AND COALESCE(SUM(e.amount), 0) <> 0)
+ORDER BY t.id;
@@ -0,0 +1,75 @@
+-- name: CreateScheduledTransfer :one
+INSERT INTO scheduled_transfers (
+    owner,
+    from_account_id,
+    to_account_id,
+    amount,
+    currency,
+    schedule,
+    next_run_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: GetScheduledTransfer :one
+SELECT * FROM scheduled_transfers
+WHERE id = $1 LIMIT 1;
+
+-- name: ListScheduledTransfersAfter :many
+SELECT * FROM scheduled_transfers
+WHERE owner = sqlc.arg(owner) AND id > sqlc.arg(after_id)
+ORDER BY id
+    LIMIT sqlc.arg(page_limit);
+
+-- name: UpdateScheduledTransfer :one
+UPDATE scheduled_transfers
+SET
+    amount = COALESCE(sqlc.narg(amount), amount),
+    schedule = COALESCE(sqlc.narg(schedule), schedule),
+    next_run_at = COALESCE(sqlc.narg(next_run_at), next_run_at),
+    status = COALESCE(sqlc.narg(status), status)
+WHERE id = sqlc.arg(id) AND status IN ('active', 'paused')
+    RETURNING *;
+
+-- name: CancelScheduledTransfer :one
+UPDATE scheduled_transfers
+SET
+    status = 'cancelled',
+    next_run_at = NULL
+WHERE id = $1 AND status IN ('active', 'paused')
+    RETURNING *;
+
+-- name: ListDueScheduledTransfers :many
+SELECT * FROM scheduled_transfers
+WHERE status = 'active' AND next_run_at <= sqlc.arg(due_before)::timestamptz
+ORDER BY next_run_at
+    LIMIT sqlc.arg(page_limit);
+
+-- name: RecordScheduledTransferRun :one
+-- moves the scheduled transfer past the occurrence and records its outcome in one statement,
+-- nothing is written when the occurrence was already handled, paused or rescheduled
+WITH advanced AS (
+    UPDATE scheduled_transfers
+    SET
+        next_run_at = sqlc.narg(next_run_at),
+        status = sqlc.arg(status)
+    WHERE id = sqlc.arg(scheduled_transfer_id) AND
+          status = 'active' AND
+          next_run_at = sqlc.arg(scheduled_for)::timestamptz
+    RETURNING id
+)
+INSERT INTO scheduled_transfer_runs (
+    scheduled_transfer_id,
+    scheduled_for,
+    transfer_id,
+    error
+)
+SELECT id, sqlc.arg(scheduled_for)::timestamptz, sqlc.narg(transfer_id)::bigint, sqlc.narg(error)::varchar
+FROM advanced
+    RETURNING *;
+
+-- name: ListScheduledTransferRuns :many
+SELECT * FROM scheduled_transfer_runs
+WHERE scheduled_transfer_id = sqlc.arg(scheduled_transfer_id) AND id > sqlc.arg(after_id)
+ORDER BY id
+    LIMIT sqlc.arg(page_limit);
@@ -2,15 +2,27 @@
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    to_amount,
+    exchange_rate,
+    currency,
+    to_currency,
+    reversal_of,
+    description,
+    client_reference,
+    metadata
 ) VALUES (
-             $1, $2, $3
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
          ) RETURNING *;
 
 -- name: GetTransfer :one
 SELECT * FROM transfers
 WHERE id = $1 LIMIT 1;
 
+-- name: GetTransferReversal :one
+SELECT * FROM transfers
+WHERE reversal_of = $1 LIMIT 1;
+
 -- name: ListTransfers :many
 SELECT * FROM transfers
 WHERE
@@ -18,4 +30,31 @@ WHERE
         to_account_id = $2
 ORDER BY id
     LIMIT $3
-OFFSET $4;
\ No newline at end of file
+OFFSET $4;
+
+-- name: ListUserTransfers :many
+SELECT t.* FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE
+    (
+        (fa.owner = sqlc.arg(owner) AND sqlc.arg(direction)::varchar <> 'in' AND
+            (sqlc.narg(counterparty_account_id)::bigint IS NULL OR t.to_account_id = sqlc.narg(counterparty_account_id)) AND
+            (sqlc.narg(amount_currency)::varchar IS NULL OR t.currency = sqlc.narg(amount_currency)) AND
+            (sqlc.narg(min_amount)::bigint IS NULL OR t.amount >= sqlc.narg(min_amount)) AND
+            (sqlc.narg(max_amount)::bigint IS NULL OR t.amount <= sqlc.narg(max_amount)))
+        OR
+        (ta.owner = sqlc.arg(owner) AND sqlc.arg(direction)::varchar <> 'out' AND
+            (sqlc.narg(counterparty_account_id)::bigint IS NULL OR t.from_account_id = sqlc.narg(counterparty_account_id)) AND
+            (sqlc.narg(amount_currency)::varchar IS NULL OR t.to_currency = sqlc.narg(amount_currency)) AND
+            (sqlc.narg(min_amount)::bigint IS NULL OR t.to_amount >= sqlc.narg(min_amount)) AND
+            (sqlc.narg(max_amount)::bigint IS NULL OR t.to_amount <= sqlc.narg(max_amount)))
+    ) AND
+    (sqlc.narg(start_time)::timestamptz IS NULL OR t.created_at >= sqlc.narg(start_time)) AND
+    (sqlc.narg(end_time)::timestamptz IS NULL OR t.created_at < sqlc.narg(end_time)) AND
+    (sqlc.narg(search)::varchar IS NULL OR strpos(lower(t.description), lower(sqlc.narg(search))) > 0) AND
+    (sqlc.narg(client_reference)::varchar IS NULL OR t.client_reference = sqlc.narg(client_reference)) AND
+    (sqlc.narg(metadata_key)::varchar IS NULL OR t.metadata ->> sqlc.narg(metadata_key) = sqlc.arg(metadata_value)::varchar) AND
+    t.id < sqlc.arg(before_id)
+ORDER BY t.id DESC
+    LIMIT sqlc.arg(page_limit);
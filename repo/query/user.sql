@@ -1,3 +1,13 @@
+-- name: ConfirmUserEmail :one
+UPDATE users
+SET
+    email = sqlc.arg(email),
+    pending_email = NULLIF(pending_email, sqlc.arg(email)),
+    is_email_verified = true
+WHERE
+        username = sqlc.arg(username) AND (email = sqlc.arg(email) OR pending_email = sqlc.arg(email))
+    RETURNING *;
+
 -- name: CreateUser :one
 INSERT INTO users (
     username,
@@ -12,13 +22,24 @@ INSERT INTO users (
 SELECT * FROM users
 WHERE username = $1 LIMIT 1;
 
+-- name: GetUserByEmail :one
+SELECT * FROM users
+WHERE email = $1 LIMIT 1;
+
+-- name: SetUserPendingEmail :one
+UPDATE users
+SET pending_email = sqlc.narg(pending_email)
+WHERE username = sqlc.arg(username)
+    RETURNING *;
+
 -- name: UpdateUser :one
 UPDATE users
 SET
     hashed_password = COALESCE(sqlc.narg(hashed_password), hashed_password),
     password_changed_at = COALESCE(sqlc.narg(password_changed_at), password_changed_at),
     full_name = COALESCE(sqlc.narg(full_name), full_name),
-    email = COALESCE(sqlc.narg(email), email)
+    email = COALESCE(sqlc.narg(email), email),
+    role = COALESCE(sqlc.narg(role), role)
 WHERE
         username = sqlc.arg(username)
     RETURNING *;
\ No newline at end of file
@@ -0,0 +1,15 @@
+-- name: CreateVerifyEmail :one
+INSERT INTO verify_emails (
+    username,
+    email,
+    token_hash,
+    expires_at
+) VALUES (
+             $1, $2, $3, $4
+         ) RETURNING *;
+
+-- name: UseVerifyEmail :one
+UPDATE verify_emails
+SET used_at = now()
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > now()
+    RETURNING *;
@@ -0,0 +1,56 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevokedTokens(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	activeID := uuid.New()
+	err := r.CreateRevokedToken(ctx, CreateRevokedTokenParams{
+		ID:        activeID,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	// revoking the same token twice is a no-op
+	err = r.CreateRevokedToken(ctx, CreateRevokedTokenParams{
+		ID:        activeID,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	expiredID := uuid.New()
+	err = r.CreateRevokedToken(ctx, CreateRevokedTokenParams{
+		ID:        expiredID,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	revoked, err := r.IsTokenRevoked(ctx, activeID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = r.IsTokenRevoked(ctx, expiredID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = r.IsTokenRevoked(ctx, uuid.New())
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	deleted, err := r.DeleteExpiredRevokedTokens(ctx)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, deleted, int64(1))
+}
@@ -0,0 +1,14 @@
+package repo
+
+// Statuses of scheduled transfers, only active ones are executed
+const (
+	ScheduledTransferActive    = "active"
+	ScheduledTransferPaused    = "paused"
+	ScheduledTransferCompleted = "completed"
+	ScheduledTransferCancelled = "cancelled"
+)
+
+// IsFinished returns true once a scheduled transfer will never run again
+func (st ScheduledTransfer) IsFinished() bool {
+	return st.Status == ScheduledTransferCompleted || st.Status == ScheduledTransferCancelled
+}
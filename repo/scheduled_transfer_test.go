@@ -0,0 +1,169 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+)
+
+func createRandomScheduledTransfer(t *testing.T, q Querier, schedule null.String, nextRunAt time.Time) ScheduledTransfer {
+	fromAccount := createRandomAccount(t)
+	toAccount := createRandomAccount(t)
+
+	arg := CreateScheduledTransferParams{
+		Owner:         fromAccount.Owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+		Currency:      fromAccount.Currency,
+		Schedule:      schedule,
+		NextRunAt:     null.TimeFrom(nextRunAt),
+	}
+
+	scheduled, err := q.CreateScheduledTransfer(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, scheduled.ID)
+	require.Equal(t, arg.Owner, scheduled.Owner)
+	require.Equal(t, arg.FromAccountID, scheduled.FromAccountID)
+	require.Equal(t, arg.ToAccountID, scheduled.ToAccountID)
+	require.Equal(t, arg.Amount, scheduled.Amount)
+	require.Equal(t, arg.Currency, scheduled.Currency)
+	require.Equal(t, arg.Schedule, scheduled.Schedule)
+	require.WithinDuration(t, nextRunAt, scheduled.NextRunAt.Time, time.Millisecond)
+	require.Equal(t, ScheduledTransferActive, scheduled.Status)
+	return scheduled
+}
+
+func TestListDueScheduledTransfers(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	due := createRandomScheduledTransfer(t, r, null.StringFrom("@daily"), time.Now().Add(-time.Minute))
+	later := createRandomScheduledTransfer(t, r, null.StringFrom("@daily"), time.Now().Add(time.Hour))
+	paused := createRandomScheduledTransfer(t, r, null.String{}, time.Now().Add(-time.Minute))
+	_, err := r.UpdateScheduledTransfer(ctx, UpdateScheduledTransferParams{
+		Status: null.StringFrom(ScheduledTransferPaused),
+		ID:     paused.ID,
+	})
+	require.NoError(t, err)
+
+	scheduledTransfers, err := r.ListDueScheduledTransfers(ctx, ListDueScheduledTransfersParams{
+		DueBefore: time.Now(),
+		PageLimit: 100,
+	})
+	require.NoError(t, err)
+
+	ids := make(map[int64]bool)
+	for _, scheduled := range scheduledTransfers {
+		ids[scheduled.ID] = true
+	}
+	require.True(t, ids[due.ID])
+	require.False(t, ids[later.ID])
+	require.False(t, ids[paused.ID])
+}
+
+func TestRecordScheduledTransferRun(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	scheduled := createRandomScheduledTransfer(t, r, null.StringFrom("@daily"), time.Now().Add(-time.Minute))
+	transfer, err := r.CreateTransfer(ctx, CreateTransferParams{
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+		ToAmount:      scheduled.Amount,
+		ExchangeRate:  "1",
+		Currency:      scheduled.Currency,
+		ToCurrency:    scheduled.Currency,
+		Metadata:      emptyMetadata,
+	})
+	require.NoError(t, err)
+
+	nextRunAt := scheduled.NextRunAt.Time.Add(24 * time.Hour)
+	arg := RecordScheduledTransferRunParams{
+		NextRunAt:           null.TimeFrom(nextRunAt),
+		Status:              ScheduledTransferActive,
+		ScheduledTransferID: scheduled.ID,
+		ScheduledFor:        scheduled.NextRunAt.Time,
+		TransferID:          sql.NullInt64{Int64: transfer.ID, Valid: true},
+	}
+
+	run, err := r.RecordScheduledTransferRun(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, scheduled.ID, run.ScheduledTransferID)
+	require.True(t, run.ScheduledFor.Equal(scheduled.NextRunAt.Time))
+	require.Equal(t, arg.TransferID, run.TransferID)
+	require.False(t, run.Error.Valid)
+
+	advanced, err := r.GetScheduledTransfer(ctx, scheduled.ID)
+	require.NoError(t, err)
+	require.WithinDuration(t, nextRunAt, advanced.NextRunAt.Time, time.Millisecond)
+
+	// the same occurrence is only recorded once
+	_, err = r.RecordScheduledTransferRun(ctx, arg)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	// the next one fails and completes the scheduled transfer
+	failed, err := r.RecordScheduledTransferRun(ctx, RecordScheduledTransferRunParams{
+		Status:              ScheduledTransferCompleted,
+		ScheduledTransferID: scheduled.ID,
+		ScheduledFor:        advanced.NextRunAt.Time,
+		Error:               null.StringFrom("insufficient funds"),
+	})
+	require.NoError(t, err)
+	require.False(t, failed.TransferID.Valid)
+	require.Equal(t, "insufficient funds", failed.Error.String)
+
+	completed, err := r.GetScheduledTransfer(ctx, scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferCompleted, completed.Status)
+	require.False(t, completed.NextRunAt.Valid)
+	require.True(t, completed.IsFinished())
+
+	runs, err := r.ListScheduledTransferRuns(ctx, ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		PageLimit:           10,
+	})
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, run.ID, runs[0].ID)
+	require.Equal(t, failed.ID, runs[1].ID)
+}
+
+func TestCancelScheduledTransfer(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	scheduled := createRandomScheduledTransfer(t, r, null.String{}, time.Now().Add(time.Hour))
+
+	cancelled, err := r.CancelScheduledTransfer(ctx, scheduled.ID)
+	require.NoError(t, err)
+	require.Equal(t, ScheduledTransferCancelled, cancelled.Status)
+	require.False(t, cancelled.NextRunAt.Valid)
+
+	// finished scheduled transfers can't be changed anymore
+	_, err = r.CancelScheduledTransfer(ctx, scheduled.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	_, err = r.UpdateScheduledTransfer(ctx, UpdateScheduledTransferParams{
+		Amount: sql.NullInt64{Int64: 20, Valid: true},
+		ID:     scheduled.ID,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
@@ -0,0 +1,100 @@
+package repo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/internal/testutils"
+)
+
+func createRandomSession(t *testing.T, username string) Session {
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	id := uuid.New()
+	arg := CreateSessionParams{
+		ID:           id,
+		Username:     username,
+		RefreshToken: testutils.RandomString(32),
+		UserAgent:    "Mozilla/5.0",
+		ClientIp:     "127.0.0.1",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		FamilyID:     id,
+	}
+
+	session, err := r.CreateSession(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.ID, session.ID)
+	require.False(t, session.IsBlocked)
+	return session
+}
+
+func TestListSessions(t *testing.T) {
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	user := createRandomUser(t)
+	session1 := createRandomSession(t, user.Username)
+	session2 := createRandomSession(t, user.Username)
+	createRandomSession(t, createRandomUser(t).Username)
+
+	sessions, err := r.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.ElementsMatch(t, []uuid.UUID{session1.ID, session2.ID}, []uuid.UUID{sessions[0].ID, sessions[1].ID})
+}
+
+func TestBlockSession(t *testing.T) {
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username)
+
+	_, err := r.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: createRandomUser(t).Username,
+	})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	blocked, err := r.BlockSession(context.Background(), BlockSessionParams{
+		ID:       session.ID,
+		Username: user.Username,
+	})
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	sessions, err := r.ListSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Empty(t, sessions)
+}
+
+func TestBlockUserSessions(t *testing.T) {
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	user := createRandomUser(t)
+	for i := 0; i < 3; i++ {
+		createRandomSession(t, user.Username)
+	}
+
+	revoked, err := r.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), revoked)
+
+	revoked, err = r.BlockUserSessions(context.Background(), user.Username)
+	require.NoError(t, err)
+	require.Zero(t, revoked)
+}
@@ -3,13 +3,30 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	null "gopkg.in/guregu/null.v4"
 )
 
 // Store interface
 type Store interface {
 	Querier
 	TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error)
+	ExternalTransferTx(ctx context.Context, arg ExternalTransferTxParams) (ExternalTransferTxResult, error)
+	SettleExternalTransferTx(ctx context.Context, id int64) (ExternalTransferTxResult, error)
+	FailExternalTransferTx(ctx context.Context, id int64) (ExternalTransferTxResult, error)
+	UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error)
+	CreateUserTx(ctx context.Context, arg CreateUserTxParams) (User, error)
+	RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error)
+	ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (User, error)
+	ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error)
+	UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxParams) (User, error)
+	UpdateProfileTx(ctx context.Context, arg UpdateProfileTxParams) (User, error)
+	VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (User, error)
 }
 
 // SQLStore provides all functions to execute db queries and transactions
@@ -44,11 +61,31 @@ func (store *SQLStore) execTx(ctx context.Context, fn func(queries *Queries) err
 	return tx.Commit()
 }
 
-// TransferTxParams contains the input parameters of the transfer transaction
+// sameCurrencyRate is recorded on transfers between accounts of the same currency
+const sameCurrencyRate = "1"
+
+// emptyMetadata is recorded on transfers that were given no metadata
+var emptyMetadata = json.RawMessage(`{}`)
+
+// TransferTxParams contains the input parameters of the transfer transaction.
+// ToAmount, ToCurrency and ExchangeRate are only set for cross-currency transfers,
+// otherwise the destination is credited with Amount in Currency.
 type TransferTxParams struct {
-	FromAccountID int64 `json:"from_account_id"`
-	ToAccountID   int64 `json:"to_account_id"`
-	Amount        int64 `json:"amount"`
+	FromAccountID int64  `json:"from_account_id"`
+	ToAccountID   int64  `json:"to_account_id"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	ToAmount      int64  `json:"to_amount"`
+	ToCurrency    string `json:"to_currency"`
+	ExchangeRate  string `json:"exchange_rate"`
+	// ReversalOf is the id of the transfer this one gives back, zero for a regular transfer
+	ReversalOf int64 `json:"reversal_of"`
+	// Description, ClientReference and Metadata are optional details recorded on the transfer, Metadata is a JSON object
+	Description     string          `json:"description"`
+	ClientReference string          `json:"client_reference"`
+	Metadata        json.RawMessage `json:"metadata"`
+	// AfterTransfer runs inside the transaction, anything it writes through q is rolled back with the transfer
+	AfterTransfer func(q Querier, result TransferTxResult) error `json:"-"`
 }
 
 // TransferTxResult is the result of the transfer transaction
@@ -60,34 +97,58 @@ type TransferTxResult struct {
 	ToEntry     Entry    `json:"to_entry"`
 }
 
-// TransferTx performs a money transfer from one account to the other.
-// It creates a transfer record, add account entries, and update accounts' balance within a single db transaction.
+// TransferTx performs a money transfer from one account to the other. It creates a transfer
+// record, adds account entries and updates the accounts' balances within a single db
+// transaction, then runs AfterTransfer inside that same transaction so its writes commit or
+// roll back together with the transfer. The transaction is rolled back with
+// ErrAccountInactive if either account is frozen or closed, with ErrInsufficientFunds if the
+// source account would end up below its overdraft limit, and with a unique violation on
+// reversal_of when the same transfer is reversed a second time.
 func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (TransferTxResult, error) {
 	var result TransferTxResult
 
+	if arg.ToAmount == 0 {
+		arg.ToAmount = arg.Amount
+		arg.ToCurrency = arg.Currency
+		arg.ExchangeRate = sameCurrencyRate
+	}
+	if len(arg.Metadata) == 0 {
+		arg.Metadata = emptyMetadata
+	}
+
 	err := store.execTx(ctx, func(q *Queries) error {
 		var err error
 
 		result.Transfer, err = q.CreateTransfer(ctx, CreateTransferParams{
-			FromAccountID: arg.FromAccountID,
-			ToAccountID:   arg.ToAccountID,
-			Amount:        arg.Amount,
+			FromAccountID:   arg.FromAccountID,
+			ToAccountID:     arg.ToAccountID,
+			Amount:          arg.Amount,
+			ToAmount:        arg.ToAmount,
+			ExchangeRate:    arg.ExchangeRate,
+			Currency:        arg.Currency,
+			ToCurrency:      arg.ToCurrency,
+			ReversalOf:      sql.NullInt64{Int64: arg.ReversalOf, Valid: arg.ReversalOf != 0},
+			Description:     null.NewString(arg.Description, arg.Description != ""),
+			ClientReference: null.NewString(arg.ClientReference, arg.ClientReference != ""),
+			Metadata:        arg.Metadata,
 		})
 		if err != nil {
 			return err
 		}
 
 		result.FromEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.FromAccountID,
-			Amount:    -arg.Amount,
+			AccountID:  arg.FromAccountID,
+			Amount:     -arg.Amount,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
 		})
 		if err != nil {
 			return err
 		}
 
 		result.ToEntry, err = q.CreateEntry(ctx, CreateEntryParams{
-			AccountID: arg.ToAccountID,
-			Amount:    arg.Amount,
+			AccountID:  arg.ToAccountID,
+			Amount:     arg.ToAmount,
+			TransferID: sql.NullInt64{Int64: result.Transfer.ID, Valid: true},
 		})
 		if err != nil {
 			return err
@@ -95,12 +156,30 @@ func (store *SQLStore) TransferTx(ctx context.Context, arg TransferTxParams) (Tr
 
 		// prevent deadlock by comparing money coming in and out
 		if arg.FromAccountID < arg.ToAccountID {
-			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.Amount)
+			result.FromAccount, result.ToAccount, err = addMoney(ctx, q, arg.FromAccountID, -arg.Amount, arg.ToAccountID, arg.ToAmount)
 		} else {
-			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.Amount, arg.FromAccountID, -arg.Amount)
+			result.ToAccount, result.FromAccount, err = addMoney(ctx, q, arg.ToAccountID, arg.ToAmount, arg.FromAccountID, -arg.Amount)
+		}
+		if err != nil {
+			return err
 		}
 
-		return err
+		// the status is read back from the locked rows as well, so an account can't be frozen or closed halfway through
+		for _, account := range []Account{result.FromAccount, result.ToAccount} {
+			if !account.IsActive() {
+				return fmt.Errorf("account [%d] is %s: %w", account.ID, account.Status, ErrAccountInactive)
+			}
+		}
+
+		// the balance is read back from the locked row, so concurrent transfers cannot both pass this check
+		if result.FromAccount.Balance < -result.FromAccount.OverdraftLimit {
+			return fmt.Errorf("account [%d]: %w", arg.FromAccountID, ErrInsufficientFunds)
+		}
+
+		if arg.AfterTransfer == nil {
+			return nil
+		}
+		return arg.AfterTransfer(q, result)
 	})
 
 	return result, err
@@ -128,3 +207,457 @@ func addMoney(
 	})
 	return
 }
+
+// ExternalTransferTxParams contains the input parameters of the external transfer transaction
+type ExternalTransferTxParams struct {
+	// Kind is ExternalTransferDeposit or ExternalTransferWithdrawal
+	Kind              string `json:"kind"`
+	AccountID         int64  `json:"account_id"`
+	Amount            int64  `json:"amount"`
+	ExternalReference string `json:"external_reference"`
+	// Settled records the transfer as already confirmed by the external system
+	Settled   bool   `json:"settled"`
+	CreatedBy string `json:"created_by"`
+}
+
+// ExternalTransferTxResult is the result of the external transfer transaction
+type ExternalTransferTxResult struct {
+	ExternalTransfer ExternalTransfer `json:"external_transfer"`
+	Account          Account          `json:"account"`
+	Entry            Entry            `json:"entry"`
+}
+
+// ExternalTransferTx records a deposit into or a withdrawal from an account against the clearing account of the
+// account's currency, which is created on first use. A withdrawal posts its entries right away so the money can't be
+// spent twice, a deposit only once it is settled. The transaction is rolled back with ErrAccountInactive if the account
+// is frozen or closed, and with ErrInsufficientFunds if a withdrawal would take it below its overdraft limit.
+func (store *SQLStore) ExternalTransferTx(ctx context.Context, arg ExternalTransferTxParams) (ExternalTransferTxResult, error) {
+	var result ExternalTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		// the customer account is always locked before the clearing account, so concurrent deposits can't deadlock
+		account, err := q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+		if !account.IsActive() {
+			return fmt.Errorf("account [%d] is %s: %w", account.ID, account.Status, ErrAccountInactive)
+		}
+
+		clearing, err := q.UpsertAccount(ctx, UpsertAccountParams{
+			Owner:    ClearingAccountOwner,
+			Currency: account.Currency,
+		})
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		post := arg.Settled || arg.Kind == ExternalTransferWithdrawal
+		transferArg := CreateExternalTransferParams{
+			Kind:              arg.Kind,
+			AccountID:         account.ID,
+			ClearingAccountID: clearing.ID,
+			Amount:            arg.Amount,
+			Currency:          account.Currency,
+			ExternalReference: arg.ExternalReference,
+			Status:            ExternalTransferPending,
+			CreatedBy:         arg.CreatedBy,
+			PostedAt:          null.NewTime(now, post),
+		}
+		if arg.Settled {
+			transferArg.Status = ExternalTransferSettled
+			transferArg.SettledAt = null.TimeFrom(now)
+		}
+		result.ExternalTransfer, err = q.CreateExternalTransfer(ctx, transferArg)
+		if err != nil {
+			return err
+		}
+
+		result.Account = account
+		if !post {
+			return nil
+		}
+
+		result.Account, result.Entry, err = postExternalTransfer(ctx, q, result.ExternalTransfer, 1)
+		if err != nil {
+			return err
+		}
+
+		// deposits are accepted even into an account that is already past its limit
+		if arg.Kind == ExternalTransferWithdrawal && result.Account.Balance < -result.Account.OverdraftLimit {
+			return fmt.Errorf("account [%d]: %w", account.ID, ErrInsufficientFunds)
+		}
+		return nil
+	})
+
+	return result, err
+}
+
+// SettleExternalTransferTx marks a pending deposit or withdrawal as confirmed by the external system, posting the
+// entries of a deposit that were held back until now. The transaction is rolled back with ErrExternalTransferNotPending
+// if the transfer was settled or failed already, the result then carries its current state, and with
+// ErrAccountInactive if a deposit would be posted to a frozen or closed account.
+func (store *SQLStore) SettleExternalTransferTx(ctx context.Context, id int64) (ExternalTransferTxResult, error) {
+	var result ExternalTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		transfer, account, err := lockPendingExternalTransfer(ctx, q, id, &result)
+		if err != nil {
+			return err
+		}
+
+		if !transfer.PostedAt.Valid {
+			if !account.IsActive() {
+				return fmt.Errorf("account [%d] is %s: %w", account.ID, account.Status, ErrAccountInactive)
+			}
+			result.Account, result.Entry, err = postExternalTransfer(ctx, q, transfer, 1)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.ExternalTransfer, err = q.SettleExternalTransfer(ctx, id)
+		return err
+	})
+
+	return result, err
+}
+
+// FailExternalTransferTx marks a pending deposit or withdrawal as rejected by the external system. Money it already
+// moved is given back with compensating entries against the clearing account, even if that takes the account past its
+// overdraft limit or the account was frozen since. The transaction is rolled back with ErrExternalTransferNotPending
+// if the transfer was settled or failed already, the result then carries its current state.
+func (store *SQLStore) FailExternalTransferTx(ctx context.Context, id int64) (ExternalTransferTxResult, error) {
+	var result ExternalTransferTxResult
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		transfer, _, err := lockPendingExternalTransfer(ctx, q, id, &result)
+		if err != nil {
+			return err
+		}
+
+		if transfer.PostedAt.Valid {
+			result.Account, result.Entry, err = postExternalTransfer(ctx, q, transfer, -1)
+			if err != nil {
+				return err
+			}
+		}
+
+		result.ExternalTransfer, err = q.FailExternalTransfer(ctx, id)
+		return err
+	})
+
+	return result, err
+}
+
+// lockPendingExternalTransfer locks an external transfer and then its account, the transfer row serializes settling
+// and failing it while the account keeps the same lock order as ExternalTransferTx
+func lockPendingExternalTransfer(ctx context.Context, q *Queries, id int64, result *ExternalTransferTxResult) (ExternalTransfer, Account, error) {
+	transfer, err := q.GetExternalTransferForUpdate(ctx, id)
+	if err != nil {
+		return transfer, Account{}, err
+	}
+	result.ExternalTransfer = transfer
+	if transfer.Status != ExternalTransferPending {
+		return transfer, Account{}, fmt.Errorf("external transfer [%d] is %s: %w", id, transfer.Status, ErrExternalTransferNotPending)
+	}
+
+	account, err := q.GetAccountForUpdate(ctx, transfer.AccountID)
+	if err != nil {
+		return transfer, account, err
+	}
+	result.Account = account
+	return transfer, account, nil
+}
+
+// postExternalTransfer moves the amount of an external transfer between its account and the clearing account,
+// sign -1 gives back money that was posted before
+func postExternalTransfer(ctx context.Context, q *Queries, transfer ExternalTransfer, sign int64) (account Account, entry Entry, err error) {
+	// clearing accounts are on the other side of every deposit and withdrawal, they may go as far below zero as needed
+	amount := sign * transfer.Amount
+	if transfer.Kind == ExternalTransferWithdrawal {
+		amount = -amount
+	}
+	externalTransferID := sql.NullInt64{Int64: transfer.ID, Valid: true}
+
+	entry, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:          transfer.AccountID,
+		Amount:             amount,
+		ExternalTransferID: externalTransferID,
+	})
+	if err != nil {
+		return
+	}
+
+	_, err = q.CreateEntry(ctx, CreateEntryParams{
+		AccountID:          transfer.ClearingAccountID,
+		Amount:             -amount,
+		ExternalTransferID: externalTransferID,
+	})
+	if err != nil {
+		return
+	}
+
+	account, _, err = addMoney(ctx, q, transfer.AccountID, amount, transfer.ClearingAccountID, -amount)
+	return
+}
+
+// UpdateAccountStatusTxParams contains the input parameters of the account status transaction
+type UpdateAccountStatusTxParams struct {
+	AccountID int64  `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+// UpdateAccountStatusTx moves an account to a new status, locking it so no transfer can change its balance meanwhile.
+// It returns ErrInvalidStatusTransition for moves the lifecycle doesn't allow and ErrAccountNotEmpty when closing an account with a non zero balance.
+func (store *SQLStore) UpdateAccountStatusTx(ctx context.Context, arg UpdateAccountStatusTxParams) (Account, error) {
+	var account Account
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		account, err = q.GetAccountForUpdate(ctx, arg.AccountID)
+		if err != nil {
+			return err
+		}
+
+		if !CanTransitionAccountStatus(account.Status, arg.Status) {
+			return fmt.Errorf("account [%d] %s to %s: %w", account.ID, account.Status, arg.Status, ErrInvalidStatusTransition)
+		}
+		if arg.Status == AccountStatusClosed && account.Balance != 0 {
+			return fmt.Errorf("account [%d]: %w", account.ID, ErrAccountNotEmpty)
+		}
+
+		account, err = q.UpdateAccountStatus(ctx, UpdateAccountStatusParams{
+			ID:     account.ID,
+			Status: arg.Status,
+		})
+		return err
+	})
+
+	return account, err
+}
+
+// RotateSessionTxParams contains the input parameters of the session rotation transaction
+type RotateSessionTxParams struct {
+	ParentID uuid.UUID           `json:"parent_id"`
+	Session  CreateSessionParams `json:"session"`
+}
+
+// RotateSessionTx marks the parent session as rotated and creates its replacement in the same family.
+// It returns ErrSessionRotated when the parent refresh token has already been exchanged.
+func (store *SQLStore) RotateSessionTx(ctx context.Context, arg RotateSessionTxParams) (Session, error) {
+	var session Session
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		parent, err := q.RotateSession(ctx, arg.ParentID)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrSessionRotated
+			}
+			return err
+		}
+
+		arg.Session.FamilyID = parent.FamilyID
+		arg.Session.ParentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		session, err = q.CreateSession(ctx, arg.Session)
+		return err
+	})
+
+	return session, err
+}
+
+// ChangePasswordTxParams contains the input parameters of the password change transaction
+type ChangePasswordTxParams struct {
+	Username       string `json:"username"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+// ChangePasswordTx stores the new password and blocks every session of the user
+func (store *SQLStore) ChangePasswordTx(ctx context.Context, arg ChangePasswordTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = changePassword(ctx, q, arg.Username, arg.HashedPassword)
+		return err
+	})
+
+	return user, err
+}
+
+// ResetPasswordTxParams contains the input parameters of the password reset transaction
+type ResetPasswordTxParams struct {
+	TokenHash      string `json:"token_hash"`
+	HashedPassword string `json:"hashed_password"`
+}
+
+// ResetPasswordTx redeems a password reset token and changes the password of its user.
+// It returns ErrInvalidResetToken when the token is unknown, expired or already used.
+func (store *SQLStore) ResetPasswordTx(ctx context.Context, arg ResetPasswordTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		resetToken, err := q.UsePasswordResetToken(ctx, arg.TokenHash)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrInvalidResetToken
+			}
+			return err
+		}
+
+		user, err = changePassword(ctx, q, resetToken.Username, arg.HashedPassword)
+		return err
+	})
+
+	return user, err
+}
+
+// UpdateUserRoleTxParams contains the input parameters of the user role update transaction
+type UpdateUserRoleTxParams struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// UpdateUserRoleTx changes the role of a user and blocks every session of the user,
+// so refresh tokens issued for the previous role can't be renewed. Nothing changes when the role is the same.
+func (store *SQLStore) UpdateUserRoleTx(ctx context.Context, arg UpdateUserRoleTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.GetUser(ctx, arg.Username)
+		if err != nil || user.Role == arg.Role {
+			return err
+		}
+
+		user, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: arg.Username,
+			Role:     null.StringFrom(arg.Role),
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.BlockUserSessions(ctx, arg.Username)
+		return err
+	})
+
+	return user, err
+}
+
+// CreateUserTxParams contains the input parameters of the create user transaction
+type CreateUserTxParams struct {
+	CreateUserParams
+	// AfterCreate runs inside the transaction, anything it writes through q is rolled back with the user
+	AfterCreate func(q Querier, user User) error `json:"-"`
+}
+
+// CreateUserTx creates a user and runs AfterCreate within the same db transaction
+func (store *SQLStore) CreateUserTx(ctx context.Context, arg CreateUserTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.CreateUser(ctx, arg.CreateUserParams)
+		if err != nil || arg.AfterCreate == nil {
+			return err
+		}
+
+		return arg.AfterCreate(q, user)
+	})
+
+	return user, err
+}
+
+// UpdateProfileTxParams contains the input parameters of the profile update transaction
+type UpdateProfileTxParams struct {
+	Username string      `json:"username"`
+	FullName null.String `json:"full_name"`
+	// Email is only stored as pending until the user verifies it
+	Email null.String `json:"email"`
+	// AfterUpdate runs inside the transaction, anything it writes through q is rolled back with the update
+	AfterUpdate func(q Querier, user User) error `json:"-"`
+}
+
+// UpdateProfileTx updates the full name of a user, records a pending email change
+// and runs AfterUpdate within the same db transaction
+func (store *SQLStore) UpdateProfileTx(ctx context.Context, arg UpdateProfileTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		var err error
+		user, err = q.UpdateUser(ctx, UpdateUserParams{
+			Username: arg.Username,
+			FullName: arg.FullName,
+		})
+		if err != nil {
+			return err
+		}
+
+		if arg.Email.Valid {
+			user, err = q.SetUserPendingEmail(ctx, SetUserPendingEmailParams{
+				PendingEmail: arg.Email,
+				Username:     arg.Username,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if arg.AfterUpdate == nil {
+			return nil
+		}
+		return arg.AfterUpdate(q, user)
+	})
+
+	return user, err
+}
+
+// VerifyEmailTxParams contains the input parameters of the email verification transaction
+type VerifyEmailTxParams struct {
+	TokenHash string `json:"token_hash"`
+}
+
+// VerifyEmailTx redeems an email verification token and marks its address as verified,
+// moving it into place first when it was a pending email change.
+// It returns ErrInvalidVerifyEmail when the token is unknown, expired, already used
+// or was superseded by a later email change.
+func (store *SQLStore) VerifyEmailTx(ctx context.Context, arg VerifyEmailTxParams) (User, error) {
+	var user User
+
+	err := store.execTx(ctx, func(q *Queries) error {
+		verifyEmail, err := q.UseVerifyEmail(ctx, arg.TokenHash)
+		if err != nil {
+			if errors.Is(err, ErrRecordNotFound) {
+				return ErrInvalidVerifyEmail
+			}
+			return err
+		}
+
+		user, err = q.ConfirmUserEmail(ctx, ConfirmUserEmailParams{
+			Email:    verifyEmail.Email,
+			Username: verifyEmail.Username,
+		})
+		if errors.Is(err, ErrRecordNotFound) {
+			return ErrInvalidVerifyEmail
+		}
+		return err
+	})
+
+	return user, err
+}
+
+func changePassword(ctx context.Context, q *Queries, username string, hashedPassword string) (User, error) {
+	user, err := q.UpdateUser(ctx, UpdateUserParams{
+		Username:          username,
+		HashedPassword:    null.StringFrom(hashedPassword),
+		PasswordChangedAt: null.TimeFrom(time.Now()),
+	})
+	if err != nil {
+		return user, err
+	}
+
+	_, err = q.BlockUserSessions(ctx, username)
+	return user, err
+}
@@ -2,10 +2,18 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"math"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/internal/testutils"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -20,12 +28,14 @@ func TestTransferTx(t *testing.T) {
 
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
-	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	// run n concurrent transfer transactions
 	n := 5
 	amount := int64(10)
 
+	account1 = fundAccount(t, store, account1, int64(n)*amount)
+	fmt.Println(">> before:", account1.Balance, account2.Balance)
+
 	errs := make(chan error)
 	results := make(chan TransferTxResult)
 
@@ -35,6 +45,7 @@ func TestTransferTx(t *testing.T) {
 				FromAccountID: account1.ID,
 				ToAccountID:   account2.ID,
 				Amount:        amount,
+				Currency:      account1.Currency,
 			})
 
 			errs <- err
@@ -69,6 +80,7 @@ func TestTransferTx(t *testing.T) {
 		assert.NotEmpty(t, fromEntry)
 		assert.Equal(t, account1.ID, fromEntry.AccountID)
 		assert.Equal(t, -amount, fromEntry.Amount)
+		assert.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, fromEntry.TransferID)
 		assert.NotZero(t, fromEntry.ID)
 		assert.NotZero(t, fromEntry.CreatedAt)
 
@@ -79,6 +91,7 @@ func TestTransferTx(t *testing.T) {
 		assert.NotEmpty(t, toEntry)
 		assert.Equal(t, account2.ID, toEntry.AccountID)
 		assert.Equal(t, amount, toEntry.Amount)
+		assert.Equal(t, sql.NullInt64{Int64: result.Transfer.ID, Valid: true}, toEntry.TransferID)
 		assert.NotZero(t, toEntry.ID)
 		assert.NotZero(t, toEntry.CreatedAt)
 
@@ -132,21 +145,27 @@ func TestTransferTxDeadLock(t *testing.T) {
 
 	account1 := createRandomAccount(t)
 	account2 := createRandomAccount(t)
-	fmt.Println(">> before:", account1.Balance, account2.Balance)
 
 	// run n concurrent transfer transactions
 	n := 10
 	amount := int64(10)
 
+	// either side may send all of its transfers before receiving any
+	account1 = fundAccount(t, store, account1, int64(n)*amount)
+	account2 = fundAccount(t, store, account2, int64(n)*amount)
+	fmt.Println(">> before:", account1.Balance, account2.Balance)
+
 	errs := make(chan error)
 
 	for i := 0; i < n; i++ {
 		fromAccountID := account1.ID
 		toAccountID := account2.ID
+		currency := account1.Currency
 
 		if i%2 == 1 {
 			fromAccountID = account2.ID
 			toAccountID = account1.ID
+			currency = account2.Currency
 		}
 
 		go func() {
@@ -154,6 +173,7 @@ func TestTransferTxDeadLock(t *testing.T) {
 				FromAccountID: fromAccountID,
 				ToAccountID:   toAccountID,
 				Amount:        amount,
+				Currency:      currency,
 			})
 			errs <- err
 		}()
@@ -178,3 +198,881 @@ func TestTransferTxDeadLock(t *testing.T) {
 	assert.Equal(t, account1.Balance, updatedAccount1.Balance)
 	assert.Equal(t, account2.Balance, updatedAccount2.Balance)
 }
+
+func TestTransferTxInsufficientFunds(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	_, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + 1,
+		Currency:      account1.Currency,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	// nothing from the failed transfer may be left behind
+	updatedAccount1, err := store.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, updatedAccount1.Balance)
+
+	updatedAccount2, err := store.GetAccount(ctx, account2.ID)
+	require.NoError(t, err)
+	require.Equal(t, account2.Balance, updatedAccount2.Balance)
+}
+
+func TestTransferTxOverdraftLimit(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account1 := createRandomAccount(t)
+	account2 := createRandomAccount(t)
+
+	overdraftLimit := int64(50)
+	account1, err := store.UpdateAccountOverdraftLimit(ctx, UpdateAccountOverdraftLimitParams{
+		ID:             account1.ID,
+		OverdraftLimit: overdraftLimit,
+	})
+	require.NoError(t, err)
+
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        account1.Balance + overdraftLimit,
+		Currency:      account1.Currency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, -overdraftLimit, result.FromAccount.Balance)
+
+	_, err = store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        1,
+		Currency:      account1.Currency,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestTransferTxInactiveAccount(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	for _, status := range []string{AccountStatusFrozen, AccountStatusClosed} {
+		active := createRandomAccount(t)
+		inactive := createRandomAccount(t)
+		inactive, err := store.UpdateAccountStatus(ctx, UpdateAccountStatusParams{ID: inactive.ID, Status: status})
+		require.NoError(t, err)
+
+		_, err = store.TransferTx(ctx, TransferTxParams{
+			FromAccountID: inactive.ID,
+			ToAccountID:   active.ID,
+			Amount:        1,
+			Currency:      inactive.Currency,
+		})
+		require.ErrorIs(t, err, ErrAccountInactive)
+
+		_, err = store.TransferTx(ctx, TransferTxParams{
+			FromAccountID: active.ID,
+			ToAccountID:   inactive.ID,
+			Amount:        1,
+			Currency:      active.Currency,
+		})
+		require.ErrorIs(t, err, ErrAccountInactive)
+
+		// nothing from the refused transfers may be left behind
+		updatedActive, err := store.GetAccount(ctx, active.ID)
+		require.NoError(t, err)
+		require.Equal(t, active.Balance, updatedActive.Balance)
+
+		updatedInactive, err := store.GetAccount(ctx, inactive.ID)
+		require.NoError(t, err)
+		require.Equal(t, inactive.Balance, updatedInactive.Balance)
+	}
+}
+
+func TestTransferTxCrossCurrency(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account1 := fundAccount(t, store, createRandomAccount(t), 100)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        100,
+		Currency:      account1.Currency,
+		ToAmount:      92,
+		ToCurrency:    account2.Currency,
+		ExchangeRate:  "0.92000000",
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, int64(100), result.Transfer.Amount)
+	require.Equal(t, int64(92), result.Transfer.ToAmount)
+	require.Equal(t, "0.92000000", result.Transfer.ExchangeRate)
+	require.Equal(t, account1.Currency, result.Transfer.Currency)
+	require.Equal(t, account2.Currency, result.Transfer.ToCurrency)
+	require.Equal(t, int64(-100), result.FromEntry.Amount)
+	require.Equal(t, int64(92), result.ToEntry.Amount)
+	require.Equal(t, account1.Balance-100, result.FromAccount.Balance)
+	require.Equal(t, account2.Balance+92, result.ToAccount.Balance)
+
+	// each side filters on the amount that moved through its own account
+	testCases := []struct {
+		owner    string
+		currency string
+		amount   int64
+		count    int
+	}{
+		{owner: account1.Owner, currency: account1.Currency, amount: 100, count: 1},
+		{owner: account2.Owner, currency: account2.Currency, amount: 92, count: 1},
+		{owner: account2.Owner, currency: account2.Currency, amount: 100, count: 0},
+	}
+	for _, tc := range testCases {
+		transfers, err := store.ListUserTransfers(ctx, ListUserTransfersParams{
+			Owner:          tc.owner,
+			AmountCurrency: null.StringFrom(tc.currency),
+			MinAmount:      sql.NullInt64{Int64: tc.amount, Valid: true},
+			MaxAmount:      sql.NullInt64{Int64: tc.amount, Valid: true},
+			BeforeID:       math.MaxInt64,
+			PageLimit:      10,
+		})
+		require.NoError(t, err)
+		require.Len(t, transfers, tc.count)
+	}
+}
+
+func TestTransferTxAfterTransfer(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account1 := fundAccount(t, store, createRandomAccount(t), 100)
+	account2 := createRandomAccount(t)
+
+	// a failing callback rolls the whole transfer back
+	_, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+		AfterTransfer: func(q Querier, result TransferTxResult) error {
+			return sql.ErrConnDone
+		},
+	})
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	unchanged, err := store.GetAccount(ctx, account1.ID)
+	require.NoError(t, err)
+	require.Equal(t, account1.Balance, unchanged.Balance)
+
+	var transferID int64
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+		AfterTransfer: func(q Querier, result TransferTxResult) error {
+			transferID = result.Transfer.ID
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, result.Transfer.ID, transferID)
+}
+
+func TestTransferTxReversal(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account1 := fundAccount(t, store, createRandomAccount(t), 100)
+	account2 := createRandomAccount(t)
+
+	original, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        40,
+		Currency:      account1.Currency,
+	})
+	require.NoError(t, err)
+	require.False(t, original.Transfer.ReversalOf.Valid)
+
+	reversalArg := TransferTxParams{
+		FromAccountID: account2.ID,
+		ToAccountID:   account1.ID,
+		Amount:        original.Transfer.ToAmount,
+		Currency:      original.Transfer.ToCurrency,
+		ReversalOf:    original.Transfer.ID,
+	}
+	reversal, err := store.TransferTx(ctx, reversalArg)
+	require.NoError(t, err)
+	require.Equal(t, original.Transfer.ID, reversal.Transfer.ReversalOf.Int64)
+	require.Equal(t, account1.Balance, reversal.ToAccount.Balance)
+	require.Equal(t, account2.Balance, reversal.FromAccount.Balance)
+
+	found, err := store.GetTransferReversal(ctx, sql.NullInt64{Int64: original.Transfer.ID, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, reversal.Transfer.ID, found.ID)
+
+	// a transfer is only reversed once
+	fundAccount(t, store, account2, 40)
+	_, err = store.TransferTx(ctx, reversalArg)
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	_, err = store.GetTransferReversal(ctx, sql.NullInt64{Int64: reversal.Transfer.ID, Valid: true})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestTransferTxDetails(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account1 := fundAccount(t, store, createRandomAccount(t), 100)
+	account2 := createRandomAccount(t)
+
+	result, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID:   account1.ID,
+		ToAccountID:     account2.ID,
+		Amount:          10,
+		Currency:        account1.Currency,
+		Description:     "Rent for March",
+		ClientReference: "INV-42",
+		Metadata:        json.RawMessage(`{"order_id":"1234"}`),
+	})
+	require.NoError(t, err)
+	require.Equal(t, null.StringFrom("Rent for March"), result.Transfer.Description)
+	require.Equal(t, null.StringFrom("INV-42"), result.Transfer.ClientReference)
+	require.JSONEq(t, `{"order_id":"1234"}`, string(result.Transfer.Metadata))
+
+	plain, err := store.TransferTx(ctx, TransferTxParams{
+		FromAccountID: account1.ID,
+		ToAccountID:   account2.ID,
+		Amount:        10,
+		Currency:      account1.Currency,
+	})
+	require.NoError(t, err)
+	require.False(t, plain.Transfer.Description.Valid)
+	require.False(t, plain.Transfer.ClientReference.Valid)
+	require.JSONEq(t, `{}`, string(plain.Transfer.Metadata))
+
+	filters := []ListUserTransfersParams{
+		{Search: null.StringFrom("rent")},
+		{ClientReference: null.StringFrom("INV-42")},
+		{MetadataKey: null.StringFrom("order_id"), MetadataValue: "1234"},
+	}
+	for _, arg := range filters {
+		arg.Owner = account1.Owner
+		arg.BeforeID = math.MaxInt64
+		arg.PageLimit = 10
+
+		transfers, err := store.ListUserTransfers(ctx, arg)
+		require.NoError(t, err)
+		require.Len(t, transfers, 1)
+		require.Equal(t, result.Transfer.ID, transfers[0].ID)
+	}
+
+	entries, err := store.ListAccountStatement(ctx, ListAccountStatementParams{
+		AccountID: account2.ID,
+		PageLimit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	require.Equal(t, result.Transfer.ID, entries[0].TransferID.Int64)
+	require.Equal(t, result.Transfer.Description, entries[0].Description)
+	require.Equal(t, result.Transfer.ClientReference, entries[0].ClientReference)
+	require.JSONEq(t, `{"order_id":"1234"}`, string(entries[0].Metadata))
+	require.False(t, entries[1].Description.Valid)
+}
+
+func TestExternalTransferTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account := createRandomAccount(t)
+	reference := testutils.RandomString(12)
+
+	deposit, err := store.ExternalTransferTx(ctx, ExternalTransferTxParams{
+		Kind:              ExternalTransferDeposit,
+		AccountID:         account.ID,
+		Amount:            100,
+		ExternalReference: reference,
+		Settled:           true,
+		CreatedBy:         account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ExternalTransferDeposit, deposit.ExternalTransfer.Kind)
+	require.Equal(t, ExternalTransferSettled, deposit.ExternalTransfer.Status)
+	require.True(t, deposit.ExternalTransfer.SettledAt.Valid)
+	require.Equal(t, account.Currency, deposit.ExternalTransfer.Currency)
+	require.Equal(t, account.Balance+100, deposit.Account.Balance)
+	require.Equal(t, int64(100), deposit.Entry.Amount)
+	require.Equal(t, sql.NullInt64{Int64: deposit.ExternalTransfer.ID, Valid: true}, deposit.Entry.ExternalTransferID)
+
+	clearing, err := store.GetAccount(ctx, deposit.ExternalTransfer.ClearingAccountID)
+	require.NoError(t, err)
+	require.True(t, clearing.IsClearing())
+	require.Equal(t, account.Currency, clearing.Currency)
+
+	withdrawal, err := store.ExternalTransferTx(ctx, ExternalTransferTxParams{
+		Kind:              ExternalTransferWithdrawal,
+		AccountID:         account.ID,
+		Amount:            40,
+		ExternalReference: reference,
+		CreatedBy:         account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ExternalTransferPending, withdrawal.ExternalTransfer.Status)
+	require.False(t, withdrawal.ExternalTransfer.SettledAt.Valid)
+	require.True(t, withdrawal.ExternalTransfer.PostedAt.Valid)
+	require.Equal(t, clearing.ID, withdrawal.ExternalTransfer.ClearingAccountID)
+	require.Equal(t, account.Balance+60, withdrawal.Account.Balance)
+	require.Equal(t, int64(-40), withdrawal.Entry.Amount)
+
+	// the clearing account mirrors every movement of the customer account
+	updatedClearing, err := store.GetAccount(ctx, clearing.ID)
+	require.NoError(t, err)
+	require.Equal(t, clearing.Balance+40, updatedClearing.Balance)
+
+	// the withdrawal was posted when it was recorded, settling it doesn't move the money again
+	settled, err := store.SettleExternalTransferTx(ctx, withdrawal.ExternalTransfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, ExternalTransferSettled, settled.ExternalTransfer.Status)
+	require.True(t, settled.ExternalTransfer.SettledAt.Valid)
+	require.Equal(t, withdrawal.ExternalTransfer.PostedAt, settled.ExternalTransfer.PostedAt)
+	require.Equal(t, account.Balance+60, settled.Account.Balance)
+	require.Zero(t, settled.Entry.ID)
+
+	_, err = store.SettleExternalTransferTx(ctx, withdrawal.ExternalTransfer.ID)
+	require.ErrorIs(t, err, ErrExternalTransferNotPending)
+
+	// external references are unique per kind
+	_, err = store.ExternalTransferTx(ctx, ExternalTransferTxParams{
+		Kind:              ExternalTransferDeposit,
+		AccountID:         account.ID,
+		Amount:            1,
+		ExternalReference: reference,
+		CreatedBy:         account.Owner,
+	})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+
+	_, err = store.ExternalTransferTx(ctx, ExternalTransferTxParams{
+		Kind:              ExternalTransferWithdrawal,
+		AccountID:         account.ID,
+		Amount:            account.Balance + 61,
+		ExternalReference: testutils.RandomString(12),
+		CreatedBy:         account.Owner,
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+
+	_, err = store.UpdateAccountStatus(ctx, UpdateAccountStatusParams{ID: account.ID, Status: AccountStatusFrozen})
+	require.NoError(t, err)
+	_, err = store.ExternalTransferTx(ctx, ExternalTransferTxParams{
+		Kind:              ExternalTransferDeposit,
+		AccountID:         account.ID,
+		Amount:            1,
+		ExternalReference: testutils.RandomString(12),
+		CreatedBy:         account.Owner,
+	})
+	require.ErrorIs(t, err, ErrAccountInactive)
+
+	updatedAccount, err := store.GetAccount(ctx, account.ID)
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+60, updatedAccount.Balance)
+}
+
+func TestPendingExternalTransferTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account := createRandomAccount(t)
+
+	// a pending deposit can't be spent before the external system confirms it
+	deposit, err := store.ExternalTransferTx(ctx, ExternalTransferTxParams{
+		Kind:              ExternalTransferDeposit,
+		AccountID:         account.ID,
+		Amount:            100,
+		ExternalReference: testutils.RandomString(12),
+		CreatedBy:         account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, ExternalTransferPending, deposit.ExternalTransfer.Status)
+	require.False(t, deposit.ExternalTransfer.PostedAt.Valid)
+	require.Equal(t, account.Balance, deposit.Account.Balance)
+	require.Zero(t, deposit.Entry.ID)
+
+	clearing, err := store.GetAccount(ctx, deposit.ExternalTransfer.ClearingAccountID)
+	require.NoError(t, err)
+
+	settled, err := store.SettleExternalTransferTx(ctx, deposit.ExternalTransfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, ExternalTransferSettled, settled.ExternalTransfer.Status)
+	require.True(t, settled.ExternalTransfer.PostedAt.Valid)
+	require.Equal(t, account.Balance+100, settled.Account.Balance)
+	require.Equal(t, int64(100), settled.Entry.Amount)
+
+	_, err = store.FailExternalTransferTx(ctx, deposit.ExternalTransfer.ID)
+	require.ErrorIs(t, err, ErrExternalTransferNotPending)
+
+	// failing a withdrawal gives the money back through the clearing account
+	withdrawal, err := store.ExternalTransferTx(ctx, ExternalTransferTxParams{
+		Kind:              ExternalTransferWithdrawal,
+		AccountID:         account.ID,
+		Amount:            30,
+		ExternalReference: testutils.RandomString(12),
+		CreatedBy:         account.Owner,
+	})
+	require.NoError(t, err)
+	require.Equal(t, account.Balance+70, withdrawal.Account.Balance)
+
+	failed, err := store.FailExternalTransferTx(ctx, withdrawal.ExternalTransfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, ExternalTransferFailed, failed.ExternalTransfer.Status)
+	require.True(t, failed.ExternalTransfer.FailedAt.Valid)
+	require.Equal(t, account.Balance+100, failed.Account.Balance)
+	require.Equal(t, int64(30), failed.Entry.Amount)
+	require.Equal(t, sql.NullInt64{Int64: withdrawal.ExternalTransfer.ID, Valid: true}, failed.Entry.ExternalTransferID)
+
+	// failing a pending deposit has nothing to give back
+	rejected, err := store.ExternalTransferTx(ctx, ExternalTransferTxParams{
+		Kind:              ExternalTransferDeposit,
+		AccountID:         account.ID,
+		Amount:            50,
+		ExternalReference: testutils.RandomString(12),
+		CreatedBy:         account.Owner,
+	})
+	require.NoError(t, err)
+
+	failed, err = store.FailExternalTransferTx(ctx, rejected.ExternalTransfer.ID)
+	require.NoError(t, err)
+	require.Equal(t, ExternalTransferFailed, failed.ExternalTransfer.Status)
+	require.Equal(t, account.Balance+100, failed.Account.Balance)
+	require.Zero(t, failed.Entry.ID)
+
+	failed, err = store.FailExternalTransferTx(ctx, rejected.ExternalTransfer.ID)
+	require.ErrorIs(t, err, ErrExternalTransferNotPending)
+	require.Equal(t, ExternalTransferFailed, failed.ExternalTransfer.Status)
+
+	updatedClearing, err := store.GetAccount(ctx, clearing.ID)
+	require.NoError(t, err)
+	require.Equal(t, clearing.Balance-100, updatedClearing.Balance)
+}
+
+func TestUpdateAccountStatusTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	account := createRandomAccount(t)
+
+	account, err := store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{AccountID: account.ID, Status: AccountStatusFrozen})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusFrozen, account.Status)
+
+	_, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{AccountID: account.ID, Status: AccountStatusFrozen})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	account, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{AccountID: account.ID, Status: AccountStatusActive})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, account.Status)
+
+	account = fundAccount(t, store, account, 1)
+	_, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{AccountID: account.ID, Status: AccountStatusClosed})
+	require.ErrorIs(t, err, ErrAccountNotEmpty)
+
+	fundAccount(t, store, account, -account.Balance)
+	account, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{AccountID: account.ID, Status: AccountStatusClosed})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusClosed, account.Status)
+	require.Zero(t, account.Balance)
+
+	_, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{AccountID: account.ID, Status: AccountStatusFrozen})
+	require.ErrorIs(t, err, ErrInvalidStatusTransition)
+
+	account, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{AccountID: account.ID, Status: AccountStatusActive})
+	require.NoError(t, err)
+	require.Equal(t, AccountStatusActive, account.Status)
+
+	_, err = store.UpdateAccountStatusTx(ctx, UpdateAccountStatusTxParams{AccountID: -1, Status: AccountStatusFrozen})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestRotateSessionTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	user := createRandomUser(t)
+	parent := createRandomSession(t, user.Username)
+
+	arg := RotateSessionTxParams{
+		ParentID: parent.ID,
+		Session: CreateSessionParams{
+			ID:           uuid.New(),
+			Username:     user.Username,
+			RefreshToken: testutils.RandomString(32),
+			UserAgent:    parent.UserAgent,
+			ClientIp:     parent.ClientIp,
+			ExpiresAt:    time.Now().Add(time.Hour),
+		},
+	}
+
+	child, err := store.RotateSessionTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Session.ID, child.ID)
+	require.Equal(t, parent.FamilyID, child.FamilyID)
+	require.Equal(t, uuid.NullUUID{UUID: parent.ID, Valid: true}, child.ParentID)
+	require.False(t, child.RotatedAt.Valid)
+
+	rotated, err := store.GetSession(ctx, parent.ID)
+	require.NoError(t, err)
+	require.True(t, rotated.RotatedAt.Valid)
+
+	// the parent refresh token can only be exchanged once
+	arg.Session.ID = uuid.New()
+	_, err = store.RotateSessionTx(ctx, arg)
+	require.ErrorIs(t, err, ErrSessionRotated)
+
+	_, err = store.GetSession(ctx, arg.Session.ID)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+
+	blocked, err := store.BlockSessionFamily(ctx, parent.FamilyID)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), blocked)
+}
+
+func TestChangePasswordTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username)
+
+	hashedPassword, err := testutils.HashPassword(testutils.RandomString(8))
+	require.NoError(t, err)
+
+	updated, err := store.ChangePasswordTx(ctx, ChangePasswordTxParams{
+		Username:       user.Username,
+		HashedPassword: hashedPassword,
+	})
+	require.NoError(t, err)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+	require.WithinDuration(t, time.Now(), updated.PasswordChangedAt, time.Minute)
+
+	// existing logins must not survive a password change
+	blocked, err := store.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+}
+
+func TestUpdateUserRoleTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username)
+
+	// the same role keeps the user logged in
+	unchanged, err := store.UpdateUserRoleTx(ctx, UpdateUserRoleTxParams{Username: user.Username, Role: user.Role})
+	require.NoError(t, err)
+	require.Equal(t, user.Role, unchanged.Role)
+
+	active, err := store.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.False(t, active.IsBlocked)
+
+	updated, err := store.UpdateUserRoleTx(ctx, UpdateUserRoleTxParams{Username: user.Username, Role: "teller"})
+	require.NoError(t, err)
+	require.Equal(t, "teller", updated.Role)
+
+	// sessions opened with the previous role must not be renewed
+	blocked, err := store.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	_, err = store.UpdateUserRoleTx(ctx, UpdateUserRoleTxParams{Username: testutils.RandomOwner(), Role: "admin"})
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func TestResetPasswordTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	user := createRandomUser(t)
+	session := createRandomSession(t, user.Username)
+
+	tokenHash := testutils.RandomString(64)
+	_, err := store.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(time.Minute),
+	})
+	require.NoError(t, err)
+
+	hashedPassword, err := testutils.HashPassword(testutils.RandomString(8))
+	require.NoError(t, err)
+
+	arg := ResetPasswordTxParams{
+		TokenHash:      tokenHash,
+		HashedPassword: hashedPassword,
+	}
+
+	updated, err := store.ResetPasswordTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, user.Username, updated.Username)
+	require.Equal(t, hashedPassword, updated.HashedPassword)
+
+	blocked, err := store.GetSession(ctx, session.ID)
+	require.NoError(t, err)
+	require.True(t, blocked.IsBlocked)
+
+	// a reset token can only be used once
+	_, err = store.ResetPasswordTx(ctx, arg)
+	require.ErrorIs(t, err, ErrInvalidResetToken)
+
+	expiredHash := testutils.RandomString(64)
+	_, err = store.CreatePasswordResetToken(ctx, CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: expiredHash,
+		ExpiresAt: time.Now().Add(-time.Minute),
+	})
+	require.NoError(t, err)
+
+	arg.TokenHash = expiredHash
+	_, err = store.ResetPasswordTx(ctx, arg)
+	require.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestCreateUserTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	hashedPassword, err := testutils.HashPassword(testutils.RandomString(6))
+	require.NoError(t, err)
+
+	var job Job
+	arg := CreateUserTxParams{
+		CreateUserParams: CreateUserParams{
+			Username:       testutils.RandomOwner(),
+			HashedPassword: hashedPassword,
+			FullName:       testutils.RandomOwner(),
+			Email:          testutils.RandomEmail(),
+		},
+		AfterCreate: func(q Querier, user User) error {
+			var err error
+			job, err = q.EnqueueJob(ctx, EnqueueJobParams{
+				Kind:        "test",
+				Payload:     []byte(`{}`),
+				MaxAttempts: 1,
+				RunAt:       time.Now(),
+			})
+			return err
+		},
+	}
+
+	user, err := store.CreateUserTx(ctx, arg)
+	require.NoError(t, err)
+	require.Equal(t, arg.Username, user.Username)
+	require.False(t, user.IsEmailVerified)
+
+	_, err = store.GetJob(ctx, job.ID)
+	require.NoError(t, err)
+
+	// the user isn't created when the callback fails
+	arg.Username = testutils.RandomOwner()
+	arg.Email = testutils.RandomEmail()
+	arg.AfterCreate = func(q Querier, user User) error {
+		return sql.ErrConnDone
+	}
+	_, err = store.CreateUserTx(ctx, arg)
+	require.ErrorIs(t, err, sql.ErrConnDone)
+
+	_, err = store.GetUser(ctx, arg.Username)
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
+func createRandomVerifyEmail(t *testing.T, q Querier, username string, email string) string {
+	tokenHash := testutils.RandomString(64)
+	_, err := q.CreateVerifyEmail(context.Background(), CreateVerifyEmailParams{
+		Username:  username,
+		Email:     email,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	require.NoError(t, err)
+	return tokenHash
+}
+
+func TestVerifyEmailTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	user := createRandomUser(t)
+	tokenHash := createRandomVerifyEmail(t, store, user.Username, user.Email)
+
+	verified, err := store.VerifyEmailTx(ctx, VerifyEmailTxParams{TokenHash: tokenHash})
+	require.NoError(t, err)
+	require.Equal(t, user.Email, verified.Email)
+	require.True(t, verified.IsEmailVerified)
+
+	_, err = store.VerifyEmailTx(ctx, VerifyEmailTxParams{TokenHash: tokenHash})
+	require.ErrorIs(t, err, ErrInvalidVerifyEmail)
+}
+
+func TestUpdateProfileTx(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	user := createRandomUser(t)
+	newFullName := testutils.RandomOwner()
+	newEmail := testutils.RandomEmail()
+
+	var tokenHash string
+	updated, err := store.UpdateProfileTx(ctx, UpdateProfileTxParams{
+		Username: user.Username,
+		FullName: null.StringFrom(newFullName),
+		Email:    null.StringFrom(newEmail),
+		AfterUpdate: func(q Querier, user User) error {
+			tokenHash = createRandomVerifyEmail(t, q, user.Username, user.PendingEmail.String)
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, newFullName, updated.FullName)
+	// the email only changes once it is verified
+	require.Equal(t, user.Email, updated.Email)
+	require.Equal(t, null.StringFrom(newEmail), updated.PendingEmail)
+
+	verified, err := store.VerifyEmailTx(ctx, VerifyEmailTxParams{TokenHash: tokenHash})
+	require.NoError(t, err)
+	require.Equal(t, newEmail, verified.Email)
+	require.False(t, verified.PendingEmail.Valid)
+	require.True(t, verified.IsEmailVerified)
+}
+
+func TestVerifyEmailTxSuperseded(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	user := createRandomUser(t)
+	var tokenHashes []string
+	for i := 0; i < 2; i++ {
+		email := testutils.RandomEmail()
+		_, err := store.UpdateProfileTx(ctx, UpdateProfileTxParams{
+			Username: user.Username,
+			Email:    null.StringFrom(email),
+		})
+		require.NoError(t, err)
+		tokenHashes = append(tokenHashes, createRandomVerifyEmail(t, store, user.Username, email))
+	}
+
+	// only the latest requested address can be confirmed
+	_, err := store.VerifyEmailTx(ctx, VerifyEmailTxParams{TokenHash: tokenHashes[0]})
+	require.ErrorIs(t, err, ErrInvalidVerifyEmail)
+
+	_, err = store.VerifyEmailTx(ctx, VerifyEmailTxParams{TokenHash: tokenHashes[1]})
+	require.NoError(t, err)
+}
+
+func TestVerifyEmailTxEmailTaken(t *testing.T) {
+	ctx := context.Background()
+
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	store := NewStore(db)
+
+	user := createRandomUser(t)
+	other := createRandomUser(t)
+
+	_, err := store.UpdateProfileTx(ctx, UpdateProfileTxParams{
+		Username: user.Username,
+		Email:    null.StringFrom(other.Email),
+	})
+	require.NoError(t, err)
+	tokenHash := createRandomVerifyEmail(t, store, user.Username, other.Email)
+
+	_, err = store.VerifyEmailTx(ctx, VerifyEmailTxParams{TokenHash: tokenHash})
+	require.Equal(t, UniqueViolation, ErrorCode(err))
+}
+
+func fundAccount(t *testing.T, store Store, account Account, amount int64) Account {
+	account, err := store.AddAccountBalance(context.Background(), AddAccountBalanceParams{
+		ID:     account.ID,
+		Amount: amount,
+	})
+	require.NoError(t, err)
+	return account
+}
@@ -0,0 +1,24 @@
+package repo
+
+import (
+	"encoding/json"
+)
+
+// MetadataMap returns the metadata recorded on the transfer, nil when there is none
+func (t Transfer) MetadataMap() map[string]string {
+	return decodeMetadata(t.Metadata)
+}
+
+// MetadataMap returns the metadata of the transfer the entry is one side of, nil when there is none
+func (r ListAccountStatementRow) MetadataMap() map[string]string {
+	return decodeMetadata(r.Metadata)
+}
+
+func decodeMetadata(raw json.RawMessage) map[string]string {
+	var metadata map[string]string
+	// metadata is only ever written from a map of strings, anything else is left out rather than failing the response
+	if err := json.Unmarshal(raw, &metadata); err != nil || len(metadata) == 0 {
+		return nil
+	}
+	return metadata
+}
@@ -7,26 +7,58 @@ package repo
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+
+	null "gopkg.in/guregu/null.v4"
 )
 
 const createTransfer = `-- name: CreateTransfer :one
 INSERT INTO transfers (
     from_account_id,
     to_account_id,
-    amount
+    amount,
+    to_amount,
+    exchange_rate,
+    currency,
+    to_currency,
+    reversal_of,
+    description,
+    client_reference,
+    metadata
 ) VALUES (
-             $1, $2, $3
-         ) RETURNING id, from_account_id, to_account_id, amount, created_at
+             $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+         ) RETURNING id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, currency, to_currency, reversal_of, description, client_reference, metadata
 `
 
 type CreateTransferParams struct {
-	FromAccountID int64 `db:"from_account_id" json:"from_account_id"`
-	ToAccountID   int64 `db:"to_account_id" json:"to_account_id"`
-	Amount        int64 `db:"amount" json:"amount"`
+	FromAccountID   int64           `db:"from_account_id" json:"from_account_id"`
+	ToAccountID     int64           `db:"to_account_id" json:"to_account_id"`
+	Amount          int64           `db:"amount" json:"amount"`
+	ToAmount        int64           `db:"to_amount" json:"to_amount"`
+	ExchangeRate    string          `db:"exchange_rate" json:"exchange_rate"`
+	Currency        string          `db:"currency" json:"currency"`
+	ToCurrency      string          `db:"to_currency" json:"to_currency"`
+	ReversalOf      sql.NullInt64   `db:"reversal_of" json:"reversal_of"`
+	Description     null.String     `db:"description" json:"description"`
+	ClientReference null.String     `db:"client_reference" json:"client_reference"`
+	Metadata        json.RawMessage `db:"metadata" json:"metadata"`
 }
 
 func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams) (Transfer, error) {
-	row := q.db.QueryRowContext(ctx, createTransfer, arg.FromAccountID, arg.ToAccountID, arg.Amount)
+	row := q.db.QueryRowContext(ctx, createTransfer,
+		arg.FromAccountID,
+		arg.ToAccountID,
+		arg.Amount,
+		arg.ToAmount,
+		arg.ExchangeRate,
+		arg.Currency,
+		arg.ToCurrency,
+		arg.ReversalOf,
+		arg.Description,
+		arg.ClientReference,
+		arg.Metadata,
+	)
 	var i Transfer
 	err := row.Scan(
 		&i.ID,
@@ -34,12 +66,20 @@ func (q *Queries) CreateTransfer(ctx context.Context, arg CreateTransferParams)
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Currency,
+		&i.ToCurrency,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ClientReference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const getTransfer = `-- name: GetTransfer :one
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, currency, to_currency, reversal_of, description, client_reference, metadata FROM transfers
 WHERE id = $1 LIMIT 1
 `
 
@@ -52,12 +92,46 @@ func (q *Queries) GetTransfer(ctx context.Context, id int64) (Transfer, error) {
 		&i.ToAccountID,
 		&i.Amount,
 		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Currency,
+		&i.ToCurrency,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ClientReference,
+		&i.Metadata,
+	)
+	return i, err
+}
+
+const getTransferReversal = `-- name: GetTransferReversal :one
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, currency, to_currency, reversal_of, description, client_reference, metadata FROM transfers
+WHERE reversal_of = $1 LIMIT 1
+`
+
+func (q *Queries) GetTransferReversal(ctx context.Context, reversalOf sql.NullInt64) (Transfer, error) {
+	row := q.db.QueryRowContext(ctx, getTransferReversal, reversalOf)
+	var i Transfer
+	err := row.Scan(
+		&i.ID,
+		&i.FromAccountID,
+		&i.ToAccountID,
+		&i.Amount,
+		&i.CreatedAt,
+		&i.ToAmount,
+		&i.ExchangeRate,
+		&i.Currency,
+		&i.ToCurrency,
+		&i.ReversalOf,
+		&i.Description,
+		&i.ClientReference,
+		&i.Metadata,
 	)
 	return i, err
 }
 
 const listTransfers = `-- name: ListTransfers :many
-SELECT id, from_account_id, to_account_id, amount, created_at FROM transfers
+SELECT id, from_account_id, to_account_id, amount, created_at, to_amount, exchange_rate, currency, to_currency, reversal_of, description, client_reference, metadata FROM transfers
 WHERE
         from_account_id = $1 OR
         to_account_id = $2
@@ -93,6 +167,111 @@ func (q *Queries) ListTransfers(ctx context.Context, arg ListTransfersParams) ([
 			&i.ToAccountID,
 			&i.Amount,
 			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Currency,
+			&i.ToCurrency,
+			&i.ReversalOf,
+			&i.Description,
+			&i.ClientReference,
+			&i.Metadata,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserTransfers = `-- name: ListUserTransfers :many
+SELECT t.id, t.from_account_id, t.to_account_id, t.amount, t.created_at, t.to_amount, t.exchange_rate, t.currency, t.to_currency, t.reversal_of, t.description, t.client_reference, t.metadata FROM transfers t
+JOIN accounts fa ON fa.id = t.from_account_id
+JOIN accounts ta ON ta.id = t.to_account_id
+WHERE
+    (
+        (fa.owner = $1 AND $2::varchar <> 'in' AND
+            ($3::bigint IS NULL OR t.to_account_id = $3) AND
+            ($4::varchar IS NULL OR t.currency = $4) AND
+            ($5::bigint IS NULL OR t.amount >= $5) AND
+            ($6::bigint IS NULL OR t.amount <= $6))
+        OR
+        (ta.owner = $1 AND $2::varchar <> 'out' AND
+            ($3::bigint IS NULL OR t.from_account_id = $3) AND
+            ($4::varchar IS NULL OR t.to_currency = $4) AND
+            ($5::bigint IS NULL OR t.to_amount >= $5) AND
+            ($6::bigint IS NULL OR t.to_amount <= $6))
+    ) AND
+    ($7::timestamptz IS NULL OR t.created_at >= $7) AND
+    ($8::timestamptz IS NULL OR t.created_at < $8) AND
+    ($9::varchar IS NULL OR strpos(lower(t.description), lower($9)) > 0) AND
+    ($10::varchar IS NULL OR t.client_reference = $10) AND
+    ($11::varchar IS NULL OR t.metadata ->> $11 = $12::varchar) AND
+    t.id < $13
+ORDER BY t.id DESC
+    LIMIT $14
+`
+
+type ListUserTransfersParams struct {
+	Owner                 string        `db:"owner" json:"owner"`
+	Direction             string        `db:"direction" json:"direction"`
+	CounterpartyAccountID sql.NullInt64 `db:"counterparty_account_id" json:"counterparty_account_id"`
+	AmountCurrency        null.String   `db:"amount_currency" json:"amount_currency"`
+	MinAmount             sql.NullInt64 `db:"min_amount" json:"min_amount"`
+	MaxAmount             sql.NullInt64 `db:"max_amount" json:"max_amount"`
+	StartTime             null.Time     `db:"start_time" json:"start_time"`
+	EndTime               null.Time     `db:"end_time" json:"end_time"`
+	Search                null.String   `db:"search" json:"search"`
+	ClientReference       null.String   `db:"client_reference" json:"client_reference"`
+	MetadataKey           null.String   `db:"metadata_key" json:"metadata_key"`
+	MetadataValue         string        `db:"metadata_value" json:"metadata_value"`
+	BeforeID              int64         `db:"before_id" json:"before_id"`
+	PageLimit             int32         `db:"page_limit" json:"page_limit"`
+}
+
+func (q *Queries) ListUserTransfers(ctx context.Context, arg ListUserTransfersParams) ([]Transfer, error) {
+	rows, err := q.db.QueryContext(ctx, listUserTransfers,
+		arg.Owner,
+		arg.Direction,
+		arg.CounterpartyAccountID,
+		arg.AmountCurrency,
+		arg.MinAmount,
+		arg.MaxAmount,
+		arg.StartTime,
+		arg.EndTime,
+		arg.Search,
+		arg.ClientReference,
+		arg.MetadataKey,
+		arg.MetadataValue,
+		arg.BeforeID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Transfer{}
+	for rows.Next() {
+		var i Transfer
+		if err := rows.Scan(
+			&i.ID,
+			&i.FromAccountID,
+			&i.ToAccountID,
+			&i.Amount,
+			&i.CreatedAt,
+			&i.ToAmount,
+			&i.ExchangeRate,
+			&i.Currency,
+			&i.ToCurrency,
+			&i.ReversalOf,
+			&i.Description,
+			&i.ClientReference,
+			&i.Metadata,
 		); err != nil {
 			return nil, err
 		}
@@ -11,6 +11,39 @@ import (
 	null "gopkg.in/guregu/null.v4"
 )
 
+const confirmUserEmail = `-- name: ConfirmUserEmail :one
+UPDATE users
+SET
+    email = $1,
+    pending_email = NULLIF(pending_email, $1),
+    is_email_verified = true
+WHERE
+        username = $2 AND (email = $1 OR pending_email = $1)
+    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, pending_email, is_email_verified
+`
+
+type ConfirmUserEmailParams struct {
+	Email    string `db:"email" json:"email"`
+	Username string `db:"username" json:"username"`
+}
+
+func (q *Queries) ConfirmUserEmail(ctx context.Context, arg ConfirmUserEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, confirmUserEmail, arg.Email, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.PendingEmail,
+		&i.IsEmailVerified,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (
     username,
@@ -19,7 +52,7 @@ INSERT INTO users (
     email
 ) VALUES (
              $1, $2, $3, $4
-         ) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+         ) RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, pending_email, is_email_verified
 `
 
 type CreateUserParams struct {
@@ -44,12 +77,15 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.PendingEmail,
+		&i.IsEmailVerified,
 	)
 	return i, err
 }
 
 const getUser = `-- name: GetUser :one
-SELECT username, hashed_password, full_name, email, password_changed_at, created_at FROM users
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, pending_email, is_email_verified FROM users
 WHERE username = $1 LIMIT 1
 `
 
@@ -63,6 +99,60 @@ func (q *Queries) GetUser(ctx context.Context, username string) (User, error) {
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.PendingEmail,
+		&i.IsEmailVerified,
+	)
+	return i, err
+}
+
+const getUserByEmail = `-- name: GetUserByEmail :one
+SELECT username, hashed_password, full_name, email, password_changed_at, created_at, role, pending_email, is_email_verified FROM users
+WHERE email = $1 LIMIT 1
+`
+
+func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByEmail, email)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.PendingEmail,
+		&i.IsEmailVerified,
+	)
+	return i, err
+}
+
+const setUserPendingEmail = `-- name: SetUserPendingEmail :one
+UPDATE users
+SET pending_email = $1
+WHERE username = $2
+    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, pending_email, is_email_verified
+`
+
+type SetUserPendingEmailParams struct {
+	PendingEmail null.String `db:"pending_email" json:"pending_email"`
+	Username     string      `db:"username" json:"username"`
+}
+
+func (q *Queries) SetUserPendingEmail(ctx context.Context, arg SetUserPendingEmailParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserPendingEmail, arg.PendingEmail, arg.Username)
+	var i User
+	err := row.Scan(
+		&i.Username,
+		&i.HashedPassword,
+		&i.FullName,
+		&i.Email,
+		&i.PasswordChangedAt,
+		&i.CreatedAt,
+		&i.Role,
+		&i.PendingEmail,
+		&i.IsEmailVerified,
 	)
 	return i, err
 }
@@ -73,10 +163,11 @@ SET
     hashed_password = COALESCE($1, hashed_password),
     password_changed_at = COALESCE($2, password_changed_at),
     full_name = COALESCE($3, full_name),
-    email = COALESCE($4, email)
+    email = COALESCE($4, email),
+    role = COALESCE($5, role)
 WHERE
-        username = $5
-    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at
+        username = $6
+    RETURNING username, hashed_password, full_name, email, password_changed_at, created_at, role, pending_email, is_email_verified
 `
 
 type UpdateUserParams struct {
@@ -84,6 +175,7 @@ type UpdateUserParams struct {
 	PasswordChangedAt null.Time   `db:"password_changed_at" json:"password_changed_at"`
 	FullName          null.String `db:"full_name" json:"full_name"`
 	Email             null.String `db:"email" json:"email"`
+	Role              null.String `db:"role" json:"role"`
 	Username          string      `db:"username" json:"username"`
 }
 
@@ -93,6 +185,7 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		arg.PasswordChangedAt,
 		arg.FullName,
 		arg.Email,
+		arg.Role,
 		arg.Username,
 	)
 	var i User
@@ -103,6 +196,9 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.PasswordChangedAt,
 		&i.CreatedAt,
+		&i.Role,
+		&i.PendingEmail,
+		&i.IsEmailVerified,
 	)
 	return i, err
 }
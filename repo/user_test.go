@@ -36,6 +36,8 @@ func createRandomUser(t *testing.T) User {
 	require.Equal(t, arg.Email, user.Email)
 	require.True(t, user.PasswordChangedAt.IsZero())
 	require.NotZero(t, user.CreatedAt)
+	require.Equal(t, "customer", user.Role)
+	require.False(t, user.IsEmailVerified)
 
 	return user
 }
@@ -63,6 +65,21 @@ func TestGetUser(t *testing.T) {
 	require.WithinDuration(t, user1.CreatedAt, user2.CreatedAt, time.Second)
 }
 
+func TestGetUserByEmail(t *testing.T) {
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	user1 := createRandomUser(t)
+	user2, err := r.GetUserByEmail(context.Background(), user1.Email)
+	require.NoError(t, err)
+	require.Equal(t, user1.Username, user2.Username)
+
+	_, err = r.GetUserByEmail(context.Background(), testutils.RandomEmail())
+	require.ErrorIs(t, err, ErrRecordNotFound)
+}
+
 func TestUpdateUserOnlyFullName(t *testing.T) {
 	db, finalizer := SetupTables(t)
 	t.Cleanup(finalizer)
@@ -105,6 +122,31 @@ func TestUpdateUserOnlyEmail(t *testing.T) {
 	require.Equal(t, oldUser.HashedPassword, updatedUser.HashedPassword)
 }
 
+func TestUpdateUserOnlyRole(t *testing.T) {
+	db, finalizer := SetupTables(t)
+	t.Cleanup(finalizer)
+
+	r := New(db)
+
+	oldUser := createRandomUser(t)
+
+	updatedUser, err := r.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		Role:     null.StringFrom("teller"),
+	})
+
+	require.NoError(t, err)
+	require.Equal(t, "teller", updatedUser.Role)
+	require.Equal(t, oldUser.Email, updatedUser.Email)
+	require.Equal(t, oldUser.FullName, updatedUser.FullName)
+
+	_, err = r.UpdateUser(context.Background(), UpdateUserParams{
+		Username: oldUser.Username,
+		Role:     null.StringFrom("superuser"),
+	})
+	require.Error(t, err)
+}
+
 func TestUpdateUserOnlyPassword(t *testing.T) {
 	db, finalizer := SetupTables(t)
 	t.Cleanup(finalizer)
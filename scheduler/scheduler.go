@@ -0,0 +1,90 @@
+// Package scheduler executes scheduled transfers once their next occurrence is due
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+)
+
+// batchSize bounds how many due scheduled transfers are read per query
+const batchSize = 100
+
+// Scheduler polls for due scheduled transfers and runs them through the bank.
+// Several schedulers can run side by side, every occurrence is still executed once.
+type Scheduler struct {
+	store     repo.Store
+	bank      *service.Bank
+	appConfig *config.Config
+}
+
+// NewScheduler creates a new Scheduler
+func NewScheduler(appConfig *config.Config, store repo.Store, bank *service.Bank) *Scheduler {
+	return &Scheduler{
+		store:     store,
+		bank:      bank,
+		appConfig: appConfig,
+	}
+}
+
+// Start runs due scheduled transfers every SchedulerPollInterval until ctx is cancelled
+func (scheduler *Scheduler) Start(ctx context.Context) error {
+	for {
+		if _, err := scheduler.RunDue(ctx); err != nil {
+			log.Error().Err(err).Msg("failed to run scheduled transfers")
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(scheduler.appConfig.SchedulerPollInterval):
+		}
+	}
+}
+
+// RunDue runs one batch of scheduled transfers whose next occurrence is due and reports how many runs were recorded.
+// Failed transfers are recorded on their run, an error is only returned when the due transfers can't be listed.
+func (scheduler *Scheduler) RunDue(ctx context.Context) (int, error) {
+	due, err := scheduler.store.ListDueScheduledTransfers(ctx, repo.ListDueScheduledTransfersParams{
+		DueBefore: time.Now(),
+		PageLimit: batchSize,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due scheduled transfers: %w", err)
+	}
+
+	recorded := 0
+	for _, scheduled := range due {
+		if ctx.Err() != nil {
+			return recorded, nil
+		}
+
+		logger := log.With().
+			Int64("scheduled_transfer_id", scheduled.ID).
+			Time("scheduled_for", scheduled.NextRunAt.Time).
+			Logger()
+
+		run, err := scheduler.bank.RunScheduledTransfer(ctx, scheduled)
+		switch {
+		case errors.Is(err, service.ErrOccurrenceHandled):
+			logger.Debug().Msg("scheduled transfer occurrence already handled")
+		case err != nil:
+			// nothing was recorded, the occurrence is attempted again on the next poll
+			logger.Error().Err(err).Msg("failed to run scheduled transfer")
+		case run.Error.Valid:
+			recorded++
+			logger.Warn().Str("error", run.Error.String).Msg("scheduled transfer failed")
+		default:
+			recorded++
+			logger.Info().Int64("transfer_id", run.TransferID.Int64).Msg("scheduled transfer executed")
+		}
+	}
+	return recorded, nil
+}
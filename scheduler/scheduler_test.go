@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/currency"
+	"github.com/simplebank/exchange"
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/service"
+)
+
+func newTestScheduler(t *testing.T) (*Scheduler, *mockdb.MockStore) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	store := mockdb.NewMockStore(ctrl)
+	fxRates, err := exchange.NewStaticRateProvider(nil)
+	require.NoError(t, err)
+
+	currencies, err := currency.NewRegistry([]string{testutils.USD})
+	require.NoError(t, err)
+
+	appConfig := &config.Config{
+		SchedulerPollInterval: time.Millisecond,
+	}
+
+	return NewScheduler(appConfig, store, service.NewBank(store, fxRates, currencies)), store
+}
+
+func TestRunDue(t *testing.T) {
+	owner := testutils.RandomOwner()
+	fromAccount := repo.Account{ID: 1, Owner: owner, Balance: 100, Currency: testutils.USD, Status: repo.AccountStatusActive}
+	toAccount := repo.Account{ID: 2, Owner: testutils.RandomOwner(), Currency: testutils.USD, Status: repo.AccountStatusActive}
+
+	executed := repo.ScheduledTransfer{
+		ID:            1,
+		Owner:         owner,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        10,
+		Currency:      testutils.USD,
+		Schedule:      null.StringFrom("@monthly"),
+		NextRunAt:     null.TimeFrom(time.Now().Add(-time.Minute)),
+		Status:        repo.ScheduledTransferActive,
+	}
+	handled := executed
+	handled.ID = 2
+	unknownAccount := executed
+	unknownAccount.ID, unknownAccount.ToAccountID = 3, 3
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, recorded int, err error)
+	}{
+		{
+			name: "NothingDue",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListDueScheduledTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]repo.ScheduledTransfer{}, nil)
+			},
+			checkResponse: func(t *testing.T, recorded int, err error) {
+				require.NoError(t, err)
+				require.Zero(t, recorded)
+			},
+		},
+		{
+			name: "Executed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListDueScheduledTransfers(gomock.Any(), gomock.Any()).Times(1).Return([]repo.ScheduledTransfer{executed, handled, unknownAccount}, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(owner)).Times(3).Return(repo.User{Username: owner, IsEmailVerified: true}, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(3).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(2).Return(toAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(int64(3))).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(2).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						result := repo.TransferTxResult{Transfer: repo.Transfer{ID: 7}}
+						return result, arg.AfterTransfer(store, result)
+					})
+				store.EXPECT().
+					RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+					Times(3).
+					DoAndReturn(func(_ context.Context, arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+						switch arg.ScheduledTransferID {
+						case handled.ID:
+							// another scheduler got there first
+							return repo.ScheduledTransferRun{}, repo.ErrRecordNotFound
+						case unknownAccount.ID:
+							require.Contains(t, arg.Error.String, "account not found")
+						}
+						return repo.ScheduledTransferRun{TransferID: arg.TransferID, Error: arg.Error}, nil
+					})
+				store.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, recorded int, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 2, recorded)
+			},
+		},
+		{
+			name: "ListError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListDueScheduledTransfers(gomock.Any(), gomock.Any()).Times(1).Return(nil, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, recorded int, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			scheduler, store := newTestScheduler(t)
+			tc.buildStubs(store)
+
+			recorded, err := scheduler.RunDue(context.Background())
+			tc.checkResponse(t, recorded, err)
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	scheduler, store := newTestScheduler(t)
+	store.EXPECT().ListDueScheduledTransfers(gomock.Any(), gomock.Any()).MinTimes(1).Return([]repo.ScheduledTransfer{}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := scheduler.Start(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
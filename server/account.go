@@ -2,39 +2,54 @@ package server
 
 import (
 	"net/http"
-
-	"github.com/simplebank/token"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/pkg/errors"
+	"github.com/simplebank/money"
 	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
 )
 
 type createAccountRequest struct {
 	Currency string `json:"currency" binding:"required,currency"`
 }
 
+type accountResponse struct {
+	ID             int64        `json:"id"`
+	Owner          string       `json:"owner"`
+	Balance        money.Amount `json:"balance"`
+	Currency       string       `json:"currency"`
+	OverdraftLimit money.Amount `json:"overdraft_limit"`
+	Status         string       `json:"status"`
+	CreatedAt      time.Time    `json:"created_at"`
+}
+
+func newAccountResponse(account repo.Account) accountResponse {
+	return accountResponse{
+		ID:             account.ID,
+		Owner:          account.Owner,
+		Balance:        account.BalanceAmount(),
+		Currency:       account.Currency,
+		OverdraftLimit: account.OverdraftLimitAmount(),
+		Status:         account.Status,
+		CreatedAt:      account.CreatedAt,
+	}
+}
+
 func (s *Server) createAccount(ctx *gin.Context) {
 	var req createAccountRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		respondError(ctx, invalidRequest(err))
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := repo.CreateAccountParams{
-		Owner:    authPayload.Username,
-		Balance:  0,
-		Currency: req.Currency,
-	}
-
-	account, err := s.store.CreateAccount(ctx, arg)
+	account, err := s.bank.CreateAccount(ctx, currentActor(ctx), req.Currency)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type getAccountRequest struct {
@@ -44,53 +59,88 @@ type getAccountRequest struct {
 func (s *Server) getAccount(ctx *gin.Context) {
 	var req getAccountRequest
 	if err := ctx.ShouldBindUri(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		respondError(ctx, invalidRequest(err))
 		return
 	}
 
-	account, err := s.store.GetAccount(ctx, req.ID)
+	account, err := s.bank.GetAccount(ctx, currentActor(ctx), req.ID)
 	if err != nil {
-		if errors.Is(err, repo.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errResponse(err))
-			return
-		}
-
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if account.Owner != authPayload.Username {
-		err := errors.New("account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
-		return
-	}
-	ctx.JSON(http.StatusOK, account)
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
 
 type listAccountRequest struct {
-	PageID   int32 `form:"page_id" binding:"required,min=1"`
-	PageSize int32 `form:"page_size" binding:"required,min=5,max=10"`
+	pageRequest
+}
+
+type listAccountsResponse struct {
+	Accounts   []accountResponse `json:"accounts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
 }
 
 func (s *Server) listAccounts(ctx *gin.Context) {
 	var req listAccountRequest
 	if err := ctx.ShouldBindQuery(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	afterID, err := req.position(0)
+	if err != nil {
+		respondError(ctx, invalidRequest(err))
 		return
 	}
+	pageSize := req.limit()
 
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	arg := repo.ListAccountsParams{
-		Owner:  authPayload.Username,
-		Limit:  req.PageSize,
-		Offset: (req.PageID - 1) * req.PageSize,
+	accounts, err := s.bank.ListAccounts(ctx, currentActor(ctx), service.ListAccountsParams{
+		AfterID: afterID,
+		// one extra row tells us whether there is another page
+		Limit: pageSize + 1,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
 	}
 
-	accounts, err := s.store.ListAccounts(ctx, arg)
+	rsp := listAccountsResponse{}
+	if len(accounts) > int(pageSize) {
+		accounts = accounts[:pageSize]
+		rsp.NextCursor = encodeCursor(accounts[pageSize-1].ID)
+	}
+
+	rsp.Accounts = make([]accountResponse, len(accounts))
+	for i, account := range accounts {
+		rsp.Accounts[i] = newAccountResponse(account)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=frozen closed"`
+}
+
+// updateAccountStatus lets owners freeze or close their accounts, reactivating them is done by staff
+func (s *Server) updateAccountStatus(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	var req updateAccountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	account, err := s.bank.UpdateAccountStatus(ctx, currentActor(ctx), uri.ID, req.Status)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, accounts)
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
 }
@@ -0,0 +1,164 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/money"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
+)
+
+// adminGetAccount looks up any account regardless of its owner, it is only routed for staff roles
+func (s *Server) adminGetAccount(ctx *gin.Context) {
+	var req getAccountRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	account, err := s.store.GetAccount(ctx, req.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			respondError(ctx, apperr.Newf(apperr.CodeAccountNotFound, "account [%d] not found", req.ID))
+			return
+		}
+
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+type updateOverdraftLimitRequest struct {
+	// decimal amount in the account's currency, "0" removes the overdraft
+	OverdraftLimit string `json:"overdraft_limit" binding:"required"`
+}
+
+func (s *Server) adminUpdateOverdraftLimit(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	var req updateOverdraftLimitRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	account, err := s.store.GetAccount(ctx, uri.ID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			respondError(ctx, apperr.Newf(apperr.CodeAccountNotFound, "account [%d] not found", uri.ID))
+			return
+		}
+
+		respondError(ctx, err)
+		return
+	}
+
+	limit, err := money.Parse(req.OverdraftLimit, account.Currency)
+	if err != nil {
+		respondError(ctx, apperr.Wrap(apperr.CodeInvalidAmount, err))
+		return
+	}
+	if limit.Minor() < 0 {
+		respondError(ctx, apperr.New(apperr.CodeInvalidAmount, "overdraft limit must not be negative"))
+		return
+	}
+
+	account, err = s.store.UpdateAccountOverdraftLimit(ctx, repo.UpdateAccountOverdraftLimitParams{
+		ID:             uri.ID,
+		OverdraftLimit: limit.Minor(),
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			respondError(ctx, apperr.Newf(apperr.CodeAccountNotFound, "account [%d] not found", uri.ID))
+			return
+		}
+
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+type adminUpdateAccountStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=active frozen closed"`
+}
+
+// adminUpdateAccountStatus moves any account through its lifecycle, including unfreezing and reopening it
+func (s *Server) adminUpdateAccountStatus(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	var req adminUpdateAccountStatusRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	account, err := s.bank.UpdateAccountStatus(ctx, currentActor(ctx), uri.ID, req.Status)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newAccountResponse(account))
+}
+
+type updateUserRoleURI struct {
+	Username string `uri:"username" binding:"required,alphanum"`
+}
+
+type updateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=customer teller admin"`
+}
+
+// adminUpdateUserRole grants a role, the user's sessions are blocked so they log in again under the new role
+func (s *Server) adminUpdateUserRole(ctx *gin.Context) {
+	var uri updateUserRoleURI
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	var req updateUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if uri.Username == authPayload.Username {
+		err := apperr.New(apperr.CodePermissionDenied, "admins cannot change their own role")
+		respondError(ctx, err)
+		return
+	}
+
+	user, err := s.store.UpdateUserRoleTx(ctx, repo.UpdateUserRoleTxParams{
+		Username: uri.Username,
+		Role:     req.Role,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			respondError(ctx, apperr.Newf(apperr.CodeUserNotFound, "user %q not found", uri.Username))
+			return
+		}
+
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
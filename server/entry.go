@@ -0,0 +1,122 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simplebank/money"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+)
+
+type listEntriesRequest struct {
+	StartDate time.Time `form:"start_date" time_format:"2006-01-02" time_utc:"1"`
+	EndDate   time.Time `form:"end_date" time_format:"2006-01-02" time_utc:"1" binding:"omitempty,gtefield=StartDate"`
+	pageRequest
+}
+
+type entryResponse struct {
+	ID        int64        `json:"id"`
+	AccountID int64        `json:"account_id"`
+	Amount    money.Amount `json:"amount"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// newEntryResponse needs the account's currency because entries don't record it
+func newEntryResponse(entry repo.Entry, currency string) entryResponse {
+	return entryResponse{
+		ID:        entry.ID,
+		AccountID: entry.AccountID,
+		Amount:    money.New(entry.Amount, currency),
+		CreatedAt: entry.CreatedAt,
+	}
+}
+
+type statementEntryResponse struct {
+	ID             int64        `json:"id"`
+	AccountID      int64        `json:"account_id"`
+	Amount         money.Amount `json:"amount"`
+	RunningBalance money.Amount `json:"running_balance"`
+	// details of the transfer the entry belongs to, left out for deposits and withdrawals
+	TransferID      *int64            `json:"transfer_id,omitempty"`
+	Description     string            `json:"description,omitempty"`
+	ClientReference string            `json:"client_reference,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+func newStatementEntryResponse(row repo.ListAccountStatementRow, currency string) statementEntryResponse {
+	rsp := statementEntryResponse{
+		ID:              row.ID,
+		AccountID:       row.AccountID,
+		Amount:          money.New(row.Amount, currency),
+		RunningBalance:  money.New(row.RunningBalance, currency),
+		Description:     row.Description.String,
+		ClientReference: row.ClientReference.String,
+		Metadata:        row.MetadataMap(),
+		CreatedAt:       row.CreatedAt,
+	}
+	if row.TransferID.Valid {
+		rsp.TransferID = &row.TransferID.Int64
+	}
+	return rsp
+}
+
+type accountStatementResponse struct {
+	Account    accountResponse          `json:"account"`
+	Entries    []statementEntryResponse `json:"entries"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) listEntries(ctx *gin.Context) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	var req listEntriesRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	afterID, err := req.position(0)
+	if err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+	pageSize := req.limit()
+
+	arg := service.AccountStatementParams{
+		AccountID: uri.ID,
+		AfterID:   afterID,
+		// one extra row tells us whether there is another page
+		Limit:    pageSize + 1,
+		FromTime: req.StartDate,
+	}
+	if !req.EndDate.IsZero() {
+		// end_date is inclusive, so the window closes at the start of the following day
+		arg.ToTime = req.EndDate.AddDate(0, 0, 1)
+	}
+
+	account, entries, err := s.bank.AccountStatement(ctx, currentActor(ctx), arg)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	rsp := accountStatementResponse{Account: newAccountResponse(account)}
+	if len(entries) > int(pageSize) {
+		entries = entries[:pageSize]
+		rsp.NextCursor = encodeCursor(entries[pageSize-1].ID)
+	}
+
+	rsp.Entries = make([]statementEntryResponse, len(entries))
+	for i, entry := range entries {
+		rsp.Entries[i] = newStatementEntryResponse(entry, account.Currency)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
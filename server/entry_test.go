@@ -0,0 +1,315 @@
+package server
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func TestListEntriesAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+
+	n := 5
+	entries := randomStatement(account, n)
+
+	type Query struct {
+		startDate string
+		endDate   string
+		cursor    string
+		pageSize  int
+	}
+
+	testCases := []struct {
+		name          string
+		accountID     int64
+		query         Query
+		setupAuth     func(t *testing.T, request *http.Request, tokenMaker token.Maker)
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			accountID: account.ID,
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := repo.ListAccountStatementParams{
+					AccountID: account.ID,
+					AfterID:   0,
+					PageLimit: int32(n + 1),
+				}
+				store.EXPECT().
+					ListAccountStatement(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(entries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchStatement(t, recorder.Body, account, entries, "")
+			},
+		},
+		{
+			name:      "HasNextPage",
+			accountID: account.ID,
+			query: Query{
+				pageSize: n - 1,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := repo.ListAccountStatementParams{
+					AccountID: account.ID,
+					AfterID:   0,
+					PageLimit: int32(n),
+				}
+				store.EXPECT().
+					ListAccountStatement(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(entries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchStatement(t, recorder.Body, account, entries[:n-1], encodeCursor(entries[n-2].ID))
+			},
+		},
+		{
+			name:      "DateRange",
+			accountID: account.ID,
+			query: Query{
+				startDate: "2023-01-01",
+				endDate:   "2023-01-31",
+				cursor:    encodeCursor(entries[n-1].ID),
+				pageSize:  n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+				arg := repo.ListAccountStatementParams{
+					AccountID: account.ID,
+					FromTime:  null.TimeFrom(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)),
+					ToTime:    null.TimeFrom(time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)),
+					AfterID:   entries[n-1].ID,
+					PageLimit: int32(n + 1),
+				}
+				store.EXPECT().
+					ListAccountStatement(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(entries, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidDateRange",
+			accountID: account.ID,
+			query: Query{
+				startDate: "2023-02-01",
+				endDate:   "2023-01-01",
+				pageSize:  n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:      "UnauthorizedUser",
+			accountID: account.ID,
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, "unauthorized_user", token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name:      "NoAuthorization",
+			accountID: account.ID,
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnauthorized, recorder.Code)
+			},
+		},
+		{
+			name:      "AccountNotFound",
+			accountID: account.ID,
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
+				store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountID: account.ID,
+			query: Query{
+				pageSize: n,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ListAccountStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]repo.ListAccountStatementRow{}, sql.ErrConnDone)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:      "InvalidPageSize",
+			accountID: account.ID,
+			query: Query{
+				pageSize: 100000,
+			},
+			setupAuth: func(t *testing.T, request *http.Request, tokenMaker token.Maker) {
+				addAuthorization(t, request, tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthenticatedUser(store, token.RoleCustomer)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("%s/accounts/%d/entries", generateRandomPort(), tc.accountID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			// Add query parameters to request URL
+			q := request.URL.Query()
+			if len(tc.query.startDate) > 0 {
+				q.Add("start_date", tc.query.startDate)
+			}
+			if len(tc.query.endDate) > 0 {
+				q.Add("end_date", tc.query.endDate)
+			}
+			if len(tc.query.cursor) > 0 {
+				q.Add("cursor", tc.query.cursor)
+			}
+			q.Add("page_size", fmt.Sprintf("%d", tc.query.pageSize))
+			request.URL.RawQuery = q.Encode()
+
+			server.setupRouter()
+
+			tc.setupAuth(t, request, server.tokenMaker)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomStatement(account repo.Account, n int) []repo.ListAccountStatementRow {
+	entries := make([]repo.ListAccountStatementRow, n)
+	balance := account.Balance
+	for i := n - 1; i >= 0; i-- {
+		amount := testutils.RandomInt(-100, 100)
+		entries[i] = repo.ListAccountStatementRow{
+			ID:             int64(i + 1),
+			AccountID:      account.ID,
+			Amount:         amount,
+			RunningBalance: balance,
+		}
+		// every other entry comes from a transfer with details, the others from deposits and withdrawals
+		if i%2 == 0 {
+			entries[i].TransferID = sql.NullInt64{Int64: int64(i + 1), Valid: true}
+			entries[i].Description = null.StringFrom(testutils.RandomString(12))
+			entries[i].Metadata = json.RawMessage(`{"order_id":"1234"}`)
+		}
+		balance -= amount
+	}
+	return entries
+}
+
+func requireBodyMatchStatement(t *testing.T, body *bytes.Buffer, account repo.Account, entries []repo.ListAccountStatementRow, nextCursor string) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotStatement accountStatementResponse
+	err = json.Unmarshal(data, &gotStatement)
+	require.NoError(t, err)
+	require.Equal(t, newAccountResponse(account), gotStatement.Account)
+	require.Len(t, gotStatement.Entries, len(entries))
+	for i, entry := range entries {
+		require.Equal(t, newStatementEntryResponse(entry, account.Currency), gotStatement.Entries[i])
+	}
+	require.Equal(t, nextCursor, gotStatement.NextCursor)
+}
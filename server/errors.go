@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+
+	"github.com/simplebank/apperr"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	problemTypePrefix  = "urn:simplebank:problem:"
+)
+
+var (
+	errUserNotFound      = apperr.New(apperr.CodeUserNotFound, "user not found")
+	errEmailAlreadyInUse = apperr.New(apperr.CodeEmailAlreadyInUse, "email address is already in use")
+)
+
+// problemResponse is an RFC 7807 problem details body extended with the stable error code
+type problemResponse struct {
+	Type     string      `json:"type"`
+	Title    string      `json:"title"`
+	Status   int         `json:"status"`
+	Detail   string      `json:"detail,omitempty"`
+	Instance string      `json:"instance,omitempty"`
+	Code     apperr.Code `json:"code"`
+}
+
+func newProblemResponse(ctx *gin.Context, err error) problemResponse {
+	code := apperr.CodeOf(err)
+	status := code.HTTPStatus()
+
+	detail := err.Error()
+	if code == apperr.CodeInternal {
+		// driver and system errors stay in the logs, clients only learn that the request failed
+		log.Err(err).Str("method", ctx.Request.Method).Str("path", ctx.Request.URL.Path).Msg("request failed")
+		detail = "the server could not complete the request"
+	}
+
+	return problemResponse{
+		Type:     problemTypePrefix + strings.ReplaceAll(strings.ToLower(string(code)), "_", "-"),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: ctx.Request.URL.Path,
+		Code:     code,
+	}
+}
+
+// respondError writes err as a problem+json body, errors without an apperr code are reported as internal errors
+func respondError(ctx *gin.Context, err error) {
+	rsp := newProblemResponse(ctx, err)
+	ctx.Header("Content-Type", problemContentType)
+	ctx.JSON(rsp.Status, rsp)
+}
+
+// abortWithError is respondError for middlewares, the remaining handlers are skipped
+func abortWithError(ctx *gin.Context, err error) {
+	ctx.Abort()
+	respondError(ctx, err)
+}
+
+// invalidRequest tags request binding and validation errors
+func invalidRequest(err error) error {
+	return apperr.Wrap(apperr.CodeInvalidArgument, err)
+}
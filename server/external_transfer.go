@@ -0,0 +1,155 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/simplebank/money"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+)
+
+type externalTransferResponse struct {
+	ID                int64        `json:"id"`
+	Kind              string       `json:"kind"`
+	AccountID         int64        `json:"account_id"`
+	Amount            money.Amount `json:"amount"`
+	ExternalReference string       `json:"external_reference"`
+	Status            string       `json:"status"`
+	CreatedBy         string       `json:"created_by"`
+	CreatedAt         time.Time    `json:"created_at"`
+	SettledAt         *time.Time   `json:"settled_at,omitempty"`
+	FailedAt          *time.Time   `json:"failed_at,omitempty"`
+}
+
+func newExternalTransferResponse(transfer repo.ExternalTransfer) externalTransferResponse {
+	return externalTransferResponse{
+		ID:                transfer.ID,
+		Kind:              transfer.Kind,
+		AccountID:         transfer.AccountID,
+		Amount:            money.New(transfer.Amount, transfer.Currency),
+		ExternalReference: transfer.ExternalReference,
+		Status:            transfer.Status,
+		CreatedBy:         transfer.CreatedBy,
+		CreatedAt:         transfer.CreatedAt,
+		SettledAt:         transfer.SettledAt.Ptr(),
+		FailedAt:          transfer.FailedAt.Ptr(),
+	}
+}
+
+type externalTransferResultResponse struct {
+	ExternalTransfer externalTransferResponse `json:"external_transfer"`
+	Account          accountResponse          `json:"account"`
+	// left out when the operation didn't move any money, such as a pending deposit
+	Entry *entryResponse `json:"entry,omitempty"`
+}
+
+func newExternalTransferResultResponse(result repo.ExternalTransferTxResult) externalTransferResultResponse {
+	rsp := externalTransferResultResponse{
+		ExternalTransfer: newExternalTransferResponse(result.ExternalTransfer),
+		Account:          newAccountResponse(result.Account),
+	}
+	if result.Entry.ID != 0 {
+		entry := newEntryResponse(result.Entry, result.Account.Currency)
+		rsp.Entry = &entry
+	}
+	return rsp
+}
+
+type externalTransferRequest struct {
+	Amount            string `json:"amount" binding:"required"`
+	Currency          string `json:"currency" binding:"required,currency"`
+	ExternalReference string `json:"external_reference" binding:"required,max=255"`
+	Settled           bool   `json:"settled"`
+}
+
+func (s *Server) createDeposit(ctx *gin.Context) {
+	s.createExternalTransfer(ctx, s.bank.Deposit)
+}
+
+func (s *Server) createWithdrawal(ctx *gin.Context) {
+	s.createExternalTransfer(ctx, s.bank.Withdraw)
+}
+
+// createExternalTransfer records a deposit or a withdrawal, they only differ in the direction the money moves
+func (s *Server) createExternalTransfer(
+	ctx *gin.Context,
+	record func(ctx context.Context, actor service.Actor, arg service.ExternalTransferParams) (repo.ExternalTransferTxResult, error),
+) {
+	var uri getAccountRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	var req externalTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	amount, err := parseAmount(req.Amount, req.Currency)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	result, err := record(ctx, currentActor(ctx), service.ExternalTransferParams{
+		AccountID:         uri.ID,
+		Amount:            amount,
+		Currency:          req.Currency,
+		ExternalReference: req.ExternalReference,
+		Settled:           req.Settled,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newExternalTransferResultResponse(result))
+}
+
+type externalTransferURI struct {
+	AccountID int64 `uri:"id" binding:"required,min=1"`
+	ID        int64 `uri:"external_transfer_id" binding:"required,min=1"`
+}
+
+func (s *Server) settleDeposit(ctx *gin.Context) {
+	s.resolveExternalTransfer(ctx, repo.ExternalTransferDeposit, s.bank.SettleExternalTransfer)
+}
+
+func (s *Server) settleWithdrawal(ctx *gin.Context) {
+	s.resolveExternalTransfer(ctx, repo.ExternalTransferWithdrawal, s.bank.SettleExternalTransfer)
+}
+
+func (s *Server) failDeposit(ctx *gin.Context) {
+	s.resolveExternalTransfer(ctx, repo.ExternalTransferDeposit, s.bank.FailExternalTransfer)
+}
+
+func (s *Server) failWithdrawal(ctx *gin.Context) {
+	s.resolveExternalTransfer(ctx, repo.ExternalTransferWithdrawal, s.bank.FailExternalTransfer)
+}
+
+// resolveExternalTransfer settles or fails a pending deposit or withdrawal once the external system answered
+func (s *Server) resolveExternalTransfer(
+	ctx *gin.Context,
+	kind string,
+	resolve func(ctx context.Context, actor service.Actor, accountID int64, kind string, id int64) (repo.ExternalTransferTxResult, error),
+) {
+	var req externalTransferURI
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	result, err := resolve(ctx, currentActor(ctx), req.AccountID, kind, req.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newExternalTransferResultResponse(result))
+}
@@ -0,0 +1,401 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func TestCreateExternalTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = testutils.USD
+	reference := testutils.RandomString(12)
+
+	deposit := randomExternalTransfer(account, repo.ExternalTransferDeposit)
+	deposit.ExternalReference = reference
+
+	testCases := []struct {
+		name          string
+		path          string
+		body          gin.H
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Deposit",
+			path: "deposits",
+			body: gin.H{"amount": requestAmount(deposit.Amount, testutils.USD), "currency": testutils.USD, "external_reference": reference},
+			role: token.RoleTeller,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := repo.ExternalTransferTxParams{
+					Kind:              repo.ExternalTransferDeposit,
+					AccountID:         account.ID,
+					Amount:            deposit.Amount,
+					ExternalReference: reference,
+					CreatedBy:         "root",
+				}
+				// a pending deposit doesn't reach the balance until it is settled
+				result := repo.ExternalTransferTxResult{ExternalTransfer: deposit, Account: account}
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				data, err := io.ReadAll(recorder.Body)
+				require.NoError(t, err)
+
+				var rsp externalTransferResultResponse
+				require.NoError(t, json.Unmarshal(data, &rsp))
+				require.Equal(t, newExternalTransferResponse(deposit), rsp.ExternalTransfer)
+				require.Equal(t, newAccountResponse(account), rsp.Account)
+				require.Nil(t, rsp.Entry)
+			},
+		},
+		{
+			name: "SettledWithdrawal",
+			path: "withdrawals",
+			body: gin.H{"amount": requestAmount(10, testutils.USD), "currency": testutils.USD, "external_reference": reference, "settled": true},
+			role: token.RoleAdmin,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := repo.ExternalTransferTxParams{
+					Kind:              repo.ExternalTransferWithdrawal,
+					AccountID:         account.ID,
+					Amount:            10,
+					ExternalReference: reference,
+					Settled:           true,
+					CreatedBy:         "root",
+				}
+				withdrawal := randomExternalTransfer(account, repo.ExternalTransferWithdrawal)
+				result := repo.ExternalTransferTxResult{ExternalTransfer: withdrawal, Account: account}
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "Customer",
+			path: "deposits",
+			body: gin.H{"amount": requestAmount(deposit.Amount, testutils.USD), "currency": testutils.USD, "external_reference": reference},
+			role: token.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+		{
+			name: "MissingReference",
+			path: "deposits",
+			body: gin.H{"amount": requestAmount(deposit.Amount, testutils.USD), "currency": testutils.USD},
+			role: token.RoleTeller,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeInvalidArgument)
+			},
+		},
+		{
+			name: "DuplicateReference",
+			path: "deposits",
+			body: gin.H{"amount": requestAmount(deposit.Amount, testutils.USD), "currency": testutils.USD, "external_reference": reference},
+			role: token.RoleTeller,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ExternalTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.ExternalTransferTxResult{}, repo.ErrUniqueViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeDuplicateReference)
+			},
+		},
+		{
+			name: "InsufficientFunds",
+			path: "withdrawals",
+			body: gin.H{"amount": requestAmount(account.Balance+1, testutils.USD), "currency": testutils.USD, "external_reference": reference},
+			role: token.RoleTeller,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().
+					ExternalTransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.ExternalTransferTxResult{}, repo.ErrInsufficientFunds)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeInsufficientFunds)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthenticatedUser(store, tc.role)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/accounts/%d/%s", account.ID, tc.path)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.setupRouter()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "root", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestSettleExternalTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = testutils.USD
+
+	pending := randomExternalTransfer(account, repo.ExternalTransferDeposit)
+	settled := pending
+	settled.Status = repo.ExternalTransferSettled
+	settled.SettledAt = null.TimeFrom(time.Now().UTC().Truncate(time.Second))
+	deposited := account
+	deposited.Balance += pending.Amount
+
+	testCases := []struct {
+		name          string
+		path          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			path: "deposits",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				result := repo.ExternalTransferTxResult{
+					ExternalTransfer: settled,
+					Account:          deposited,
+					Entry:            repo.Entry{ID: 1, AccountID: account.ID, Amount: pending.Amount},
+				}
+				store.EXPECT().SettleExternalTransferTx(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp externalTransferResultResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, repo.ExternalTransferSettled, rsp.ExternalTransfer.Status)
+				require.NotNil(t, rsp.ExternalTransfer.SettledAt)
+				require.Equal(t, newAccountResponse(deposited), rsp.Account)
+				require.NotNil(t, rsp.Entry)
+			},
+		},
+		{
+			name: "WrongKind",
+			path: "withdrawals",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().SettleExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeExternalTransferNotFound)
+			},
+		},
+		{
+			name: "AlreadySettled",
+			path: "deposits",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(settled, nil)
+				store.EXPECT().SettleExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeAlreadySettled)
+			},
+		},
+		{
+			name: "AccountInactive",
+			path: "deposits",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().
+					SettleExternalTransferTx(gomock.Any(), gomock.Eq(pending.ID)).
+					Times(1).
+					Return(repo.ExternalTransferTxResult{}, repo.ErrAccountInactive)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeAccountInactive)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthenticatedUser(store, token.RoleTeller)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/%s/%d/settle", account.ID, tc.path, pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			server.setupRouter()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "root", token.RoleTeller, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestFailExternalTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	account.Currency = testutils.USD
+
+	pending := randomExternalTransfer(account, repo.ExternalTransferWithdrawal)
+	failed := pending
+	failed.Status = repo.ExternalTransferFailed
+	failed.FailedAt = null.TimeFrom(time.Now().UTC().Truncate(time.Second))
+	refunded := account
+	refunded.Balance += pending.Amount
+
+	testCases := []struct {
+		name          string
+		role          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			role: token.RoleTeller,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				// the withdrawal was posted when it was recorded, failing it gives the money back
+				result := repo.ExternalTransferTxResult{
+					ExternalTransfer: failed,
+					Account:          refunded,
+					Entry:            repo.Entry{ID: 1, AccountID: account.ID, Amount: pending.Amount},
+				}
+				store.EXPECT().FailExternalTransferTx(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp externalTransferResultResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, repo.ExternalTransferFailed, rsp.ExternalTransfer.Status)
+				require.NotNil(t, rsp.ExternalTransfer.FailedAt)
+				require.Equal(t, newAccountResponse(refunded), rsp.Account)
+				require.NotNil(t, rsp.Entry)
+			},
+		},
+		{
+			name: "AlreadyFailed",
+			role: token.RoleTeller,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(failed, nil)
+				store.EXPECT().FailExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeAlreadyFailed)
+			},
+		},
+		{
+			name: "Customer",
+			role: token.RoleCustomer,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().FailExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusForbidden, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthenticatedUser(store, tc.role)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/accounts/%d/withdrawals/%d/fail", account.ID, pending.ID)
+			request, err := http.NewRequest(http.MethodPost, url, nil)
+			require.NoError(t, err)
+
+			server.setupRouter()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "root", tc.role, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func randomExternalTransfer(account repo.Account, kind string) repo.ExternalTransfer {
+	return repo.ExternalTransfer{
+		ID:                testutils.RandomInt(1, 1000),
+		Kind:              kind,
+		AccountID:         account.ID,
+		ClearingAccountID: testutils.RandomInt(1001, 2000),
+		Amount:            testutils.RandomInt(1, 100),
+		Currency:          account.Currency,
+		ExternalReference: testutils.RandomString(12),
+		Status:            repo.ExternalTransferPending,
+		CreatedBy:         "root",
+	}
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
+)
+
+const (
+	idempotencyKeyHeader    = "Idempotency-Key"
+	maxIdempotencyKeyLength = 255
+	// idempotencyKeyTimeout is how long a key stays in flight before a retry may take it over,
+	// it outlasts any request so only keys left behind by a crash or a failed save are reclaimed
+	idempotencyKeyTimeout = 5 * time.Minute
+)
+
+var errRequestInProgress = apperr.New(apperr.CodeRequestInProgress, "a request with this idempotency key is still being processed")
+
+// bodyRecorder holds back everything the handler writes until the response is stored with the key
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// WriteHeaderNow is deferred to flush so the status can still change when the response can't be stored
+func (w *bodyRecorder) WriteHeaderNow() {}
+
+// flush sends the held back response to the client
+func (w *bodyRecorder) flush() {
+	w.ResponseWriter.WriteHeaderNow()
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		log.Err(err).Msg("cannot write idempotent response")
+	}
+}
+
+// hashRequest fingerprints a request so a reused key can be matched against the original call
+func hashRequest(method string, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte(path))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// idempotencyMiddleware creates a gin middleware that replays the stored response
+// when a request is retried with the same Idempotency-Key header
+func idempotencyMiddleware(store repo.Store) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(idempotencyKeyHeader)
+		if len(key) == 0 {
+			ctx.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			err := apperr.Newf(apperr.CodeInvalidArgument, "idempotency key must be at most %d characters", maxIdempotencyKeyLength)
+			abortWithError(ctx, err)
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			abortWithError(ctx, invalidRequest(err))
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		requestHash := hashRequest(ctx.Request.Method, ctx.Request.URL.Path, body)
+
+		record, err := store.GetIdempotencyKey(ctx, repo.GetIdempotencyKeyParams{
+			Username: authPayload.Username,
+			Key:      key,
+		})
+		switch {
+		case err == nil:
+			if record.RequestHash != requestHash {
+				err := apperr.New(apperr.CodeIdempotencyKeyReused, "idempotency key was already used for a different request")
+				abortWithError(ctx, err)
+				return
+			}
+
+			if record.ResponseCode != 0 {
+				ctx.Data(int(record.ResponseCode), gin.MIMEJSON, record.ResponseBody)
+				ctx.Abort()
+				return
+			}
+
+			// the request that took the key never stored its response, the key is handed over once it is old enough
+			_, err = store.ReclaimIdempotencyKey(ctx, repo.ReclaimIdempotencyKeyParams{
+				Username:        authPayload.Username,
+				Key:             key,
+				RequestHash:     requestHash,
+				AbandonedBefore: time.Now().Add(-idempotencyKeyTimeout),
+			})
+			if errors.Is(err, repo.ErrRecordNotFound) {
+				abortWithError(ctx, errRequestInProgress)
+				return
+			}
+			if err != nil {
+				abortWithError(ctx, err)
+				return
+			}
+		case errors.Is(err, repo.ErrRecordNotFound):
+			_, err = store.CreateIdempotencyKey(ctx, repo.CreateIdempotencyKeyParams{
+				Username:    authPayload.Username,
+				Key:         key,
+				RequestHash: requestHash,
+			})
+			if err != nil {
+				if repo.ErrorCode(err) == repo.UniqueViolation {
+					abortWithError(ctx, errRequestInProgress)
+					return
+				}
+				abortWithError(ctx, err)
+				return
+			}
+		default:
+			abortWithError(ctx, err)
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: ctx.Writer, body: &bytes.Buffer{}}
+		ctx.Writer = recorder
+		ctx.Next()
+		ctx.Writer = recorder.ResponseWriter
+
+		// only successful responses are kept, failed requests release the key so the client can retry
+		status := recorder.Status()
+		if status < http.StatusOK || status >= http.StatusMultipleChoices {
+			err = store.DeleteIdempotencyKey(ctx, repo.DeleteIdempotencyKeyParams{
+				Username: authPayload.Username,
+				Key:      key,
+			})
+			if err != nil {
+				log.Err(err).Str("key", key).Msg("cannot release idempotency key")
+			}
+			recorder.flush()
+			return
+		}
+
+		_, err = store.UpdateIdempotencyKeyResponse(ctx, repo.UpdateIdempotencyKeyResponseParams{
+			Username:     authPayload.Username,
+			Key:          key,
+			ResponseCode: int32(status),
+			ResponseBody: recorder.body.Bytes(),
+		})
+		if err != nil {
+			// a response a retry can't replay must not reach the client as the final answer
+			respondError(ctx, err)
+			return
+		}
+		recorder.flush()
+	}
+}
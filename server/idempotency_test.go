@@ -0,0 +1,298 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	user, _ := randomUser(t)
+	account := randomAccount(user.Username)
+	key := "e4b5c6d7-create-account"
+
+	body := gin.H{
+		"currency": account.Currency,
+	}
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	requestHash := hashRequest(http.MethodPost, "/accounts", data)
+
+	accountJSON, err := json.Marshal(newAccountResponse(account))
+	require.NoError(t, err)
+
+	getArg := repo.GetIdempotencyKeyParams{
+		Username: user.Username,
+		Key:      key,
+	}
+
+	testCases := []struct {
+		name          string
+		key           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "NoKey",
+			key:  "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+		{
+			name: "FirstRequest",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{}, repo.ErrRecordNotFound)
+
+				createArg := repo.CreateIdempotencyKeyParams{
+					Username:    user.Username,
+					Key:         key,
+					RequestHash: requestHash,
+				}
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Eq(createArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{Username: user.Username, Key: key, RequestHash: requestHash}, nil)
+
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+
+				updateArg := repo.UpdateIdempotencyKeyResponseParams{
+					Username:     user.Username,
+					Key:          key,
+					ResponseCode: http.StatusOK,
+					ResponseBody: accountJSON,
+				}
+				store.EXPECT().UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Eq(updateArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "Replay",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{
+						Username:     user.Username,
+						Key:          key,
+						RequestHash:  requestHash,
+						ResponseCode: http.StatusOK,
+						ResponseBody: accountJSON,
+					}, nil)
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "DifferentRequest",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{
+						Username:     user.Username,
+						Key:          key,
+						RequestHash:  hashRequest(http.MethodPost, "/accounts", []byte(`{"currency":"XXX"}`)),
+						ResponseCode: http.StatusOK,
+						ResponseBody: accountJSON,
+					}, nil)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name: "InProgress",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{
+						Username:    user.Username,
+						Key:         key,
+						RequestHash: requestHash,
+					}, nil)
+				store.EXPECT().ReclaimIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.IdempotencyKey{}, repo.ErrRecordNotFound)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+		{
+			name: "AbandonedKeyReclaimed",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{
+						Username:    user.Username,
+						Key:         key,
+						RequestHash: requestHash,
+						CreatedAt:   time.Now().Add(-time.Hour),
+					}, nil)
+				store.EXPECT().
+					ReclaimIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.ReclaimIdempotencyKeyParams) (repo.IdempotencyKey, error) {
+						require.Equal(t, user.Username, arg.Username)
+						require.Equal(t, key, arg.Key)
+						require.Equal(t, requestHash, arg.RequestHash)
+						require.WithinDuration(t, time.Now().Add(-idempotencyKeyTimeout), arg.AbandonedBefore, time.Second)
+						return repo.IdempotencyKey{Username: user.Username, Key: key, RequestHash: requestHash}, nil
+					})
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+				store.EXPECT().UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.IdempotencyKey{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchAccount(t, recorder.Body, account)
+			},
+		},
+		{
+			name: "SaveResponseError",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{}, repo.ErrRecordNotFound)
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.IdempotencyKey{}, nil)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(1).Return(account, nil)
+				store.EXPECT().UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.IdempotencyKey{}, sql.ErrConnDone)
+				store.EXPECT().DeleteIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				// the account is not reported as created when a retry couldn't replay it
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeInternal)
+			},
+		},
+		{
+			name: "HandlerFailureReleasesKey",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Eq(getArg)).
+					Times(1).
+					Return(repo.IdempotencyKey{}, repo.ErrRecordNotFound)
+				store.EXPECT().CreateIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.IdempotencyKey{}, nil)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.Account{}, sql.ErrConnDone)
+				store.EXPECT().DeleteIdempotencyKey(gomock.Any(), gomock.Eq(repo.DeleteIdempotencyKeyParams{
+					Username: user.Username,
+					Key:      key,
+				})).Times(1)
+				store.EXPECT().UpdateIdempotencyKeyResponse(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name: "InternalError",
+			key:  key,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.IdempotencyKey{}, sql.ErrConnDone)
+				store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthenticatedUser(store, token.RoleCustomer)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("%s/accounts", generateRandomPort())
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			if len(tc.key) > 0 {
+				request.Header.Set(idempotencyKeyHeader, tc.key)
+			}
+
+			server.setupRouter()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestIdempotencyKeyTooLong(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetIdempotencyKey(gomock.Any(), gomock.Any()).Times(0)
+	stubAuthenticatedUser(store, token.RoleCustomer)
+
+	server := newTestServer(t, store)
+	server.setupRouter()
+
+	request, err := http.NewRequest(http.MethodPost, "/transfers", bytes.NewReader([]byte(`{}`)))
+	require.NoError(t, err)
+	request.Header.Set(idempotencyKeyHeader, string(bytes.Repeat([]byte("k"), maxIdempotencyKeyLength+1)))
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, "user", token.RoleCustomer, time.Minute)
+
+	recorder := httptest.NewRecorder()
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+}
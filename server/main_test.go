@@ -1,13 +1,22 @@
 package server
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
 	"os"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
 
+	"github.com/simplebank/apperr"
 	"github.com/simplebank/config"
+	"github.com/simplebank/currency"
 	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
 )
 
 func newTestServer(t *testing.T, store repo.Store) *Server {
@@ -16,7 +25,7 @@ func newTestServer(t *testing.T, store repo.Store) *Server {
 		t.Fatalf("failed to create app config: %s", err)
 	}
 
-	server, err := NewServer(appConfig, store)
+	server, err := NewServer(appConfig, store, token.NewMemoryDenylist())
 	if err != nil {
 		t.Fatalf("failed to run server: %s", err)
 	}
@@ -24,6 +33,50 @@ func newTestServer(t *testing.T, store repo.Store) *Server {
 	return server
 }
 
+type eqEnqueueJobParamsMatcher struct {
+	kind    string
+	payload interface{}
+}
+
+func (e eqEnqueueJobParamsMatcher) Matches(x interface{}) bool {
+	arg, ok := x.(repo.EnqueueJobParams)
+	if !ok || arg.Kind != e.kind {
+		return false
+	}
+
+	payload, err := json.Marshal(e.payload)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(payload, arg.Payload)
+}
+
+func (e eqEnqueueJobParamsMatcher) String() string {
+	return fmt.Sprintf("matches job %s with payload %+v", e.kind, e.payload)
+}
+
+// EqEnqueueJobParams matches a job of the given kind whose payload encodes to the same json as payload
+func EqEnqueueJobParams(kind string, payload interface{}) gomock.Matcher {
+	return eqEnqueueJobParamsMatcher{kind, payload}
+}
+
+// requestAmount formats minor units the way clients send amounts, as a decimal string in the major unit
+func requestAmount(minor int64, currencyCode string) string {
+	cur, _ := currency.Lookup(currencyCode)
+	return cur.Format(minor)
+}
+
+// requireProblem checks that the response is a problem+json body carrying the given error code
+func requireProblem(t *testing.T, recorder *httptest.ResponseRecorder, code apperr.Code) {
+	require.Equal(t, problemContentType, recorder.Header().Get("Content-Type"))
+
+	var rsp problemResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	require.Equal(t, code, rsp.Code)
+	require.Equal(t, recorder.Code, rsp.Status)
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 
@@ -1,12 +1,14 @@
 package server
 
 import (
-	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
 	"github.com/simplebank/token"
 )
 
@@ -14,41 +16,117 @@ const (
 	authorizationHeaderKey  = "authorization"
 	authorizationTypeBearer = "bearer"
 	authorizationPayloadKey = "authorization_payload"
+	authorizationUserKey    = "authorization_user"
 )
 
 // AuthMiddleware creates a gin middleware for authorization
-func authMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+func authMiddleware(tokenMaker token.Maker, denylist token.Denylist, store repo.Store) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 
 		if len(authorizationHeader) == 0 {
-			err := errors.New("authorization header is not provided")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
+			err := apperr.New(apperr.CodeUnauthenticated, "authorization header is not provided")
+			abortWithError(ctx, err)
 			return
 		}
 
 		fields := strings.Fields(authorizationHeader)
 		if len(fields) < 2 {
-			err := errors.New("invalid authorization header format")
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
+			err := apperr.New(apperr.CodeUnauthenticated, "invalid authorization header format")
+			abortWithError(ctx, err)
 			return
 		}
 
 		authorizationType := strings.ToLower(fields[0])
 		if authorizationType != authorizationTypeBearer {
-			err := fmt.Errorf("unsupported authorization type %s", authorizationType)
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
+			err := apperr.Newf(apperr.CodeUnauthenticated, "unsupported authorization type %s", authorizationType)
+			abortWithError(ctx, err)
 			return
 		}
 
 		accessToken := fields[1]
 		payload, err := tokenMaker.VerifyToken(accessToken)
 		if err != nil {
-			ctx.AbortWithStatusJSON(http.StatusUnauthorized, errResponse(err))
+			abortWithError(ctx, service.TokenError(err))
+			return
+		}
+
+		revoked, err := denylist.IsRevoked(ctx, payload.ID)
+		if err != nil {
+			abortWithError(ctx, err)
+			return
+		}
+		if revoked {
+			abortWithError(ctx, service.ErrRevokedToken)
+			return
+		}
+
+		// changing the password invalidates every token issued before it
+		user, err := store.GetUser(ctx, payload.Username)
+		if err != nil {
+			if errors.Is(err, repo.ErrRecordNotFound) {
+				abortWithError(ctx, apperr.New(apperr.CodeUnauthenticated, "user no longer exists"))
+				return
+			}
+			abortWithError(ctx, err)
+			return
+		}
+		if payload.IssuedAt.Before(user.PasswordChangedAt) {
+			abortWithError(ctx, service.ErrRevokedToken)
+			return
+		}
+
+		// a role change takes effect on access tokens already handed out,
+		// their ids aren't recorded so they can't be put on the denylist one by one
+		if payload.Role != user.Role {
+			abortWithError(ctx, service.ErrRevokedToken)
 			return
 		}
 
 		ctx.Set(authorizationPayloadKey, payload)
+		ctx.Set(authorizationUserKey, user)
 		ctx.Next()
 	}
 }
+
+// roleMiddleware creates a gin middleware that only lets through tokens issued for one of the given roles.
+// It must run after authMiddleware.
+func roleMiddleware(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+		for _, role := range roles {
+			if authPayload.Role == role {
+				ctx.Next()
+				return
+			}
+		}
+
+		err := apperr.Newf(apperr.CodePermissionDenied, "role %q is not allowed to access this resource", authPayload.Role)
+		abortWithError(ctx, err)
+	}
+}
+
+// verifiedEmailMiddleware creates a gin middleware that only lets through users who verified their email.
+// It must run after authMiddleware.
+func verifiedEmailMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user := ctx.MustGet(authorizationUserKey).(repo.User)
+		if !user.IsEmailVerified {
+			abortWithError(ctx, service.ErrEmailNotVerified)
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// currentActor describes the authenticated user to the service layer, it must run after authMiddleware
+func currentActor(ctx *gin.Context) service.Actor {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user := ctx.MustGet(authorizationUserKey).(repo.User)
+	return service.Actor{
+		Username:      authPayload.Username,
+		Role:          authPayload.Role,
+		EmailVerified: user.IsEmailVerified,
+	}
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+const defaultPageSize = 20
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// pageRequest holds the keyset pagination parameters shared by all list endpoints
+type pageRequest struct {
+	Cursor   string `form:"cursor"`
+	PageSize int32  `form:"page_size" binding:"omitempty,min=1,max=50"`
+}
+
+// limit returns the requested page size, falling back to defaultPageSize
+func (r pageRequest) limit() int32 {
+	if r.PageSize == 0 {
+		return defaultPageSize
+	}
+	return r.PageSize
+}
+
+// position decodes the cursor into the id of the last row already seen,
+// fallback is returned when the client asks for the first page
+func (r pageRequest) position(fallback int64) (int64, error) {
+	if len(r.Cursor) == 0 {
+		return fallback, nil
+	}
+	return decodeCursor(r.Cursor)
+}
+
+// encodeCursor hides the keyset position behind an opaque token
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+
+	id, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || id < 1 {
+		return 0, errInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+	"github.com/simplebank/token"
+	"github.com/simplebank/worker"
+)
+
+type changePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required,min=6"`
+	NewPassword string `json:"new_password" binding:"required,min=6,nefield=OldPassword"`
+}
+
+func (s *Server) changePassword(ctx *gin.Context) {
+	var req changePasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	err = testutils.CheckPassword(req.OldPassword, user.HashedPassword)
+	if err != nil {
+		respondError(ctx, service.ErrInvalidCredentials)
+		return
+	}
+
+	hashedPassword, err := testutils.HashPassword(req.NewPassword)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	user, err = s.store.ChangePasswordTx(ctx, repo.ChangePasswordTxParams{
+		Username:       user.Username,
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type requestPasswordResetRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+func (s *Server) requestPasswordReset(ctx *gin.Context) {
+	var req requestPasswordResetRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	user, err := s.store.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		// unknown emails get the same answer so the endpoint can't be used to find accounts
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			ctx.Status(http.StatusAccepted)
+			return
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	err = worker.EnqueueSendPasswordReset(ctx, s.store, worker.PayloadSendPasswordReset{
+		Username: user.Username,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
+
+type resetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+func (s *Server) resetPassword(ctx *gin.Context) {
+	var req resetPasswordRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	hashedPassword, err := testutils.HashPassword(req.NewPassword)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	user, err := s.store.ResetPasswordTx(ctx, repo.ResetPasswordTxParams{
+		TokenHash:      token.HashSecretToken(req.Token),
+		HashedPassword: hashedPassword,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidResetToken) {
+			respondError(ctx, apperr.Wrap(apperr.CodeInvalidResetToken, err))
+			return
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/money"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+)
+
+type scheduledTransferResponse struct {
+	ID            int64        `json:"id"`
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        money.Amount `json:"amount"`
+	Schedule      string       `json:"schedule,omitempty"`
+	NextRunAt     *time.Time   `json:"next_run_at,omitempty"`
+	Status        string       `json:"status"`
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+func newScheduledTransferResponse(scheduled repo.ScheduledTransfer) scheduledTransferResponse {
+	return scheduledTransferResponse{
+		ID:            scheduled.ID,
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        money.New(scheduled.Amount, scheduled.Currency),
+		Schedule:      scheduled.Schedule.String,
+		NextRunAt:     scheduled.NextRunAt.Ptr(),
+		Status:        scheduled.Status,
+		CreatedAt:     scheduled.CreatedAt,
+	}
+}
+
+type scheduledTransferRunResponse struct {
+	ID           int64     `json:"id"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	TransferID   *int64    `json:"transfer_id,omitempty"`
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func newScheduledTransferRunResponse(run repo.ScheduledTransferRun) scheduledTransferRunResponse {
+	rsp := scheduledTransferRunResponse{
+		ID:           run.ID,
+		ScheduledFor: run.ScheduledFor,
+		Error:        run.Error.String,
+		CreatedAt:    run.CreatedAt,
+	}
+	if run.TransferID.Valid {
+		rsp.TransferID = &run.TransferID.Int64
+	}
+	return rsp
+}
+
+type createScheduledTransferRequest struct {
+	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
+	Amount        string `json:"amount" binding:"required"`
+	Currency      string `json:"currency" binding:"required,currency"`
+	// cron expression or descriptor such as @monthly, left out for a one-off transfer
+	Schedule string    `json:"schedule" binding:"max=255"`
+	StartAt  time.Time `json:"start_at" binding:"required_without=Schedule"`
+}
+
+func (s *Server) createScheduledTransfer(ctx *gin.Context) {
+	var req createScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	amount, err := parseAmount(req.Amount, req.Currency)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	scheduled, err := s.bank.CreateScheduledTransfer(ctx, currentActor(ctx), service.CreateScheduledTransferParams{
+		FromAccountID: req.FromAccountID,
+		ToAccountID:   req.ToAccountID,
+		Amount:        amount,
+		Currency:      req.Currency,
+		Schedule:      req.Schedule,
+		StartAt:       req.StartAt,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+type getScheduledTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (s *Server) getScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	scheduled, err := s.bank.GetScheduledTransfer(ctx, currentActor(ctx), req.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+type listScheduledTransfersRequest struct {
+	pageRequest
+}
+
+type listScheduledTransfersResponse struct {
+	ScheduledTransfers []scheduledTransferResponse `json:"scheduled_transfers"`
+	NextCursor         string                      `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) listScheduledTransfers(ctx *gin.Context) {
+	var req listScheduledTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	afterID, err := req.position(0)
+	if err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+	pageSize := req.limit()
+
+	scheduledTransfers, err := s.bank.ListScheduledTransfers(ctx, currentActor(ctx), service.ListScheduledTransfersParams{
+		AfterID: afterID,
+		// one extra row tells us whether there is another page
+		Limit: pageSize + 1,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	rsp := listScheduledTransfersResponse{}
+	if len(scheduledTransfers) > int(pageSize) {
+		scheduledTransfers = scheduledTransfers[:pageSize]
+		rsp.NextCursor = encodeCursor(scheduledTransfers[pageSize-1].ID)
+	}
+
+	rsp.ScheduledTransfers = make([]scheduledTransferResponse, len(scheduledTransfers))
+	for i, scheduled := range scheduledTransfers {
+		rsp.ScheduledTransfers[i] = newScheduledTransferResponse(scheduled)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type listScheduledTransferRunsRequest struct {
+	pageRequest
+}
+
+type listScheduledTransferRunsResponse struct {
+	Runs       []scheduledTransferRunResponse `json:"runs"`
+	NextCursor string                         `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) listScheduledTransferRuns(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	var req listScheduledTransferRunsRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	afterID, err := req.position(0)
+	if err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+	pageSize := req.limit()
+
+	runs, err := s.bank.ListScheduledTransferRuns(ctx, currentActor(ctx), service.ListScheduledTransferRunsParams{
+		ScheduledTransferID: uri.ID,
+		AfterID:             afterID,
+		Limit:               pageSize + 1,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	rsp := listScheduledTransferRunsResponse{}
+	if len(runs) > int(pageSize) {
+		runs = runs[:pageSize]
+		rsp.NextCursor = encodeCursor(runs[pageSize-1].ID)
+	}
+
+	rsp.Runs = make([]scheduledTransferRunResponse, len(runs))
+	for i, run := range runs {
+		rsp.Runs[i] = newScheduledTransferRunResponse(run)
+	}
+	ctx.JSON(http.StatusOK, rsp)
+}
+
+type updateScheduledTransferRequest struct {
+	Amount string `json:"amount" binding:"required_with=Currency"`
+	// currency of the amount, it must be the scheduled transfer's currency
+	Currency string    `json:"currency" binding:"required_with=Amount,omitempty,currency"`
+	Schedule string    `json:"schedule" binding:"max=255"`
+	StartAt  time.Time `json:"start_at"`
+	Paused   *bool     `json:"paused"`
+}
+
+func (s *Server) updateScheduledTransfer(ctx *gin.Context) {
+	var uri getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&uri); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	var req updateScheduledTransferRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	if req.Amount == "" && req.Schedule == "" && req.StartAt.IsZero() && req.Paused == nil {
+		err := apperr.New(apperr.CodeInvalidArgument, "at least one of amount, schedule, start_at or paused is required")
+		respondError(ctx, err)
+		return
+	}
+
+	var amount int64
+	if req.Amount != "" {
+		var err error
+		amount, err = parseAmount(req.Amount, req.Currency)
+		if err != nil {
+			respondError(ctx, err)
+			return
+		}
+	}
+
+	scheduled, err := s.bank.UpdateScheduledTransfer(ctx, currentActor(ctx), service.UpdateScheduledTransferParams{
+		ID:       uri.ID,
+		Amount:   amount,
+		Currency: req.Currency,
+		Schedule: req.Schedule,
+		StartAt:  req.StartAt,
+		Paused:   null.BoolFromPtr(req.Paused),
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
+
+func (s *Server) cancelScheduledTransfer(ctx *gin.Context) {
+	var req getScheduledTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	scheduled, err := s.bank.CancelScheduledTransfer(ctx, currentActor(ctx), req.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newScheduledTransferResponse(scheduled))
+}
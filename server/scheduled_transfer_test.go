@@ -0,0 +1,367 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func TestCreateScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	fromAccount := randomAccount(user.Username)
+	fromAccount.Currency = testutils.USD
+	toAccount := randomAccount(testutils.RandomOwner())
+	toAccount.Currency = testutils.USD
+	fromAccount.ID, toAccount.ID = 1, 2
+
+	scheduled := randomScheduledTransfer(user.Username)
+	scheduled.FromAccountID, scheduled.ToAccountID = fromAccount.ID, toAccount.ID
+
+	body := func(schedule string) gin.H {
+		req := gin.H{
+			"from_account_id": fromAccount.ID,
+			"to_account_id":   toAccount.ID,
+			"amount":          requestAmount(scheduled.Amount, testutils.USD),
+			"currency":        testutils.USD,
+		}
+		if schedule != "" {
+			req["schedule"] = schedule
+		}
+		return req
+	}
+
+	stubAccounts := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: body("@monthly"),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.CreateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+						require.Equal(t, user.Username, arg.Owner)
+						require.Equal(t, null.StringFrom("@monthly"), arg.Schedule)
+						return scheduled, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp scheduledTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, scheduled.ID, rsp.ID)
+				require.Equal(t, scheduled.Schedule.String, rsp.Schedule)
+				require.Equal(t, scheduled.Status, rsp.Status)
+				require.WithinDuration(t, scheduled.NextRunAt.Time, *rsp.NextRunAt, time.Second)
+			},
+		},
+		{
+			name: "OneOffWithoutStart",
+			body: body(""),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidSchedule",
+			body: body("every month"),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeInvalidSchedule)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthenticatedUser(store, token.RoleCustomer)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, "/scheduled_transfers", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.setupRouter()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := randomScheduledTransfer(user.Username)
+	cancelled := scheduled
+	cancelled.Status, cancelled.NextRunAt = repo.ScheduledTransferCancelled, null.Time{}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "Pause",
+			body: gin.H{"paused": true},
+			buildStubs: func(store *mockdb.MockStore) {
+				paused := scheduled
+				paused.Status = repo.ScheduledTransferPaused
+
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				arg := repo.UpdateScheduledTransferParams{
+					Status: null.StringFrom(repo.ScheduledTransferPaused),
+					ID:     scheduled.ID,
+				}
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1).Return(paused, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp scheduledTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, repo.ScheduledTransferPaused, rsp.Status)
+			},
+		},
+		{
+			name: "EmptyBody",
+			body: gin.H{},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeInvalidArgument)
+			},
+		},
+		{
+			name: "AmountWithoutCurrency",
+			body: gin.H{"amount": requestAmount(10, scheduled.Currency)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeInvalidArgument)
+			},
+		},
+		{
+			name: "Finished",
+			body: gin.H{"amount": requestAmount(10, scheduled.Currency), "currency": scheduled.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(cancelled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeScheduledTransferFinished)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthenticatedUser(store, token.RoleCustomer)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			server.setupRouter()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCancelScheduledTransferAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := randomScheduledTransfer(user.Username)
+
+	testCases := []struct {
+		name          string
+		username      string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(store *mockdb.MockStore) {
+				cancelled := scheduled
+				cancelled.Status, cancelled.NextRunAt = repo.ScheduledTransferCancelled, null.Time{}
+
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(cancelled, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var rsp scheduledTransferResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+				require.Equal(t, repo.ScheduledTransferCancelled, rsp.Status)
+				require.Nil(t, rsp.NextRunAt)
+			},
+		},
+		{
+			name:     "OtherOwner",
+			username: testutils.RandomOwner(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().CancelScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+				requireProblem(t, recorder, apperr.CodeScheduledTransferNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+			stubAuthenticatedUser(store, token.RoleCustomer)
+
+			server := newTestServer(t, store)
+			recorder := httptest.NewRecorder()
+
+			url := fmt.Sprintf("/scheduled_transfers/%d", scheduled.ID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			server.setupRouter()
+
+			addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, tc.username, token.RoleCustomer, time.Minute)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListScheduledTransferRunsAPI(t *testing.T) {
+	user, _ := randomUser(t)
+	scheduled := randomScheduledTransfer(user.Username)
+
+	runs := []repo.ScheduledTransferRun{
+		{ID: 1, ScheduledTransferID: scheduled.ID, TransferID: sql.NullInt64{Int64: 7, Valid: true}},
+		{ID: 2, ScheduledTransferID: scheduled.ID, Error: null.StringFrom("insufficient funds")},
+	}
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+	arg := repo.ListScheduledTransferRunsParams{
+		ScheduledTransferID: scheduled.ID,
+		PageLimit:           defaultPageSize + 1,
+	}
+	store.EXPECT().ListScheduledTransferRuns(gomock.Any(), gomock.Eq(arg)).Times(1).Return(runs, nil)
+	stubAuthenticatedUser(store, token.RoleCustomer)
+
+	server := newTestServer(t, store)
+	recorder := httptest.NewRecorder()
+
+	url := fmt.Sprintf("/scheduled_transfers/%d/runs", scheduled.ID)
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	server.setupRouter()
+
+	addAuthorization(t, request, server.tokenMaker, authorizationTypeBearer, user.Username, token.RoleCustomer, time.Minute)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var rsp listScheduledTransferRunsResponse
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &rsp))
+	require.Len(t, rsp.Runs, 2)
+	require.Equal(t, int64(7), *rsp.Runs[0].TransferID)
+	require.Empty(t, rsp.Runs[0].Error)
+	require.Nil(t, rsp.Runs[1].TransferID)
+	require.Equal(t, "insufficient funds", rsp.Runs[1].Error)
+	require.Empty(t, rsp.NextCursor)
+}
+
+func randomScheduledTransfer(owner string) repo.ScheduledTransfer {
+	return repo.ScheduledTransfer{
+		ID:            testutils.RandomInt(1, 1000),
+		Owner:         owner,
+		FromAccountID: testutils.RandomInt(1, 1000),
+		ToAccountID:   testutils.RandomInt(1, 1000),
+		Amount:        testutils.RandomInt(1, 100),
+		Currency:      testutils.USD,
+		Schedule:      null.StringFrom("@monthly"),
+		NextRunAt:     null.TimeFrom(time.Now().Add(time.Hour)),
+		Status:        repo.ScheduledTransferActive,
+	}
+}
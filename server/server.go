@@ -14,7 +14,10 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/simplebank/config"
+	"github.com/simplebank/currency"
+	"github.com/simplebank/exchange"
 	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
 
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
@@ -24,23 +27,41 @@ type Server struct {
 	appConfig  *config.Config
 	store      repo.Store
 	tokenMaker token.Maker
+	denylist   token.Denylist
+	bank       *service.Bank
 	router     *gin.Engine
 }
 
-func NewServer(appConfig *config.Config, store repo.Store) (*Server, error) {
+func NewServer(appConfig *config.Config, store repo.Store, denylist token.Denylist) (*Server, error) {
 	tokenMaker, err := token.NewPasetoMaker(appConfig.TokenSymmetricKey)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create token maker: %w", err)
 	}
 
+	fxRates, err := exchange.NewStaticRateProvider(appConfig.FXRates)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create exchange rate provider: %w", err)
+	}
+
+	currencies, err := currency.NewRegistry(appConfig.Currencies)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create currency registry: %w", err)
+	}
+
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
-		err := v.RegisterValidation("currency", validCurrency)
+		err := v.RegisterValidation("currency", validCurrency(currencies))
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	server := &Server{appConfig: appConfig, store: store, tokenMaker: tokenMaker}
+	server := &Server{
+		appConfig:  appConfig,
+		store:      store,
+		tokenMaker: tokenMaker,
+		denylist:   denylist,
+		bank:       service.NewBank(store, fxRates, currencies),
+	}
 	return server, nil
 }
 
@@ -80,17 +101,51 @@ func (s *Server) setupRouter() {
 	router.POST("/users", s.createUser)
 	router.POST("/users/login", s.loginUser)
 	router.POST("/tokens/renew_access", s.renewAccessToken)
-
-	authRoutes := router.Group("/").Use(authMiddleware(s.tokenMaker))
-	authRoutes.POST("/accounts", s.createAccount)
+	router.POST("/users/password/reset_request", s.requestPasswordReset)
+	router.POST("/users/password/reset", s.resetPassword)
+	router.POST("/users/verify_email", s.verifyEmail)
+
+	authRoutes := router.Group("/").Use(authMiddleware(s.tokenMaker, s.denylist, s.store))
+	authRoutes.POST("/users/logout", s.logoutUser)
+	authRoutes.PUT("/users/password", s.changePassword)
+	authRoutes.GET("/users/me", s.getCurrentUser)
+	authRoutes.PATCH("/users/me", s.updateCurrentUser)
+	authRoutes.POST("/users/verify_email/resend", s.resendVerifyEmail)
+	authRoutes.POST("/accounts", idempotencyMiddleware(s.store), s.createAccount)
 	authRoutes.GET("/accounts/:id", s.getAccount)
 	authRoutes.GET("/accounts", s.listAccounts)
-
-	authRoutes.POST("/transfers", s.createTransfer)
+	authRoutes.GET("/accounts/:id/entries", s.listEntries)
+	authRoutes.PUT("/accounts/:id/status", s.updateAccountStatus)
+
+	tellerOnly := roleMiddleware(token.RoleTeller, token.RoleAdmin)
+	authRoutes.POST("/accounts/:id/deposits", tellerOnly, idempotencyMiddleware(s.store), s.createDeposit)
+	authRoutes.POST("/accounts/:id/deposits/:external_transfer_id/settle", tellerOnly, s.settleDeposit)
+	authRoutes.POST("/accounts/:id/deposits/:external_transfer_id/fail", tellerOnly, s.failDeposit)
+	authRoutes.POST("/accounts/:id/withdrawals", tellerOnly, idempotencyMiddleware(s.store), s.createWithdrawal)
+	authRoutes.POST("/accounts/:id/withdrawals/:external_transfer_id/settle", tellerOnly, s.settleWithdrawal)
+	authRoutes.POST("/accounts/:id/withdrawals/:external_transfer_id/fail", tellerOnly, s.failWithdrawal)
+
+	authRoutes.POST("/transfers", verifiedEmailMiddleware(), idempotencyMiddleware(s.store), s.createTransfer)
+	authRoutes.GET("/transfers", s.listTransfers)
+	authRoutes.GET("/transfers/:id", s.getTransfer)
+	authRoutes.POST("/transfers/:id/reverse", idempotencyMiddleware(s.store), s.reverseTransfer)
+
+	authRoutes.POST("/scheduled_transfers", verifiedEmailMiddleware(), idempotencyMiddleware(s.store), s.createScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers", s.listScheduledTransfers)
+	authRoutes.GET("/scheduled_transfers/:id", s.getScheduledTransfer)
+	authRoutes.PATCH("/scheduled_transfers/:id", s.updateScheduledTransfer)
+	authRoutes.DELETE("/scheduled_transfers/:id", s.cancelScheduledTransfer)
+	authRoutes.GET("/scheduled_transfers/:id/runs", s.listScheduledTransferRuns)
+
+	authRoutes.GET("/sessions", s.listSessions)
+	authRoutes.DELETE("/sessions/:id", s.revokeSession)
+	authRoutes.POST("/sessions/revoke_all", s.revokeAllSessions)
+
+	staffRoutes := router.Group("/admin").Use(authMiddleware(s.tokenMaker, s.denylist, s.store), roleMiddleware(token.RoleTeller, token.RoleAdmin))
+	staffRoutes.GET("/accounts/:id", s.adminGetAccount)
+	staffRoutes.PUT("/accounts/:id/overdraft_limit", roleMiddleware(token.RoleAdmin), s.adminUpdateOverdraftLimit)
+	staffRoutes.PUT("/accounts/:id/status", s.adminUpdateAccountStatus)
+	staffRoutes.PUT("/users/:username/role", roleMiddleware(token.RoleAdmin), s.adminUpdateUserRole)
 
 	s.router = router
 }
-
-func errResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
-}
@@ -1,13 +1,16 @@
 package server
 
 import (
-	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/simplebank/apperr"
 	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
 )
 
 type renewAccessTokenRequest struct {
@@ -15,70 +18,138 @@ type renewAccessTokenRequest struct {
 }
 
 type renewAccessTokenResponse struct {
-	AccessToken          string    `json:"access_token"`
-	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
+	SessionID             uuid.UUID `json:"session_id"`
+	AccessToken           string    `json:"access_token"`
+	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
+	RefreshToken          string    `json:"refresh_token"`
+	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 }
 
 func (s *Server) renewAccessToken(ctx *gin.Context) {
 	var req renewAccessTokenRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		respondError(ctx, invalidRequest(err))
 		return
 	}
 
 	refreshPayload, err := s.tokenMaker.VerifyToken(req.RefreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		respondError(ctx, service.TokenError(err))
 		return
 	}
 
 	session, err := s.store.GetSession(ctx, refreshPayload.ID)
 	if err != nil {
 		if errors.Is(err, repo.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errResponse(err))
+			respondError(ctx, service.ErrSessionNotFound)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
 	if session.IsBlocked {
-		err := fmt.Errorf("blocked session")
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		err := apperr.New(apperr.CodeSessionInvalid, "blocked session")
+		respondError(ctx, err)
 		return
 	}
 
 	if session.Username != refreshPayload.Username {
-		err := fmt.Errorf("incorrect session user")
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		err := apperr.New(apperr.CodeSessionInvalid, "incorrect session user")
+		respondError(ctx, err)
 		return
 	}
 
 	if session.RefreshToken != req.RefreshToken {
-		err := fmt.Errorf("mismatched session token")
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		err := apperr.New(apperr.CodeSessionInvalid, "mismatched session token")
+		respondError(ctx, err)
 		return
 	}
 
 	if time.Now().After(session.ExpiresAt) {
-		err := fmt.Errorf("expired session")
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		err := apperr.New(apperr.CodeSessionInvalid, "expired session")
+		respondError(ctx, err)
+		return
+	}
+
+	// a refresh token is single use, seeing it again means it has leaked
+	if session.RotatedAt.Valid {
+		s.blockSessionFamily(ctx, session)
+		return
+	}
+
+	// the role is read again so a user whose role was changed doesn't keep the old one by renewing
+	user, err := s.store.GetUser(ctx, session.Username)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	refreshToken, newRefreshPayload, err := s.tokenMaker.CreateToken(
+		user.Username,
+		user.Role,
+		s.appConfig.RefreshTokenDuration,
+	)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	newSession, err := s.store.RotateSessionTx(ctx, repo.RotateSessionTxParams{
+		ParentID: session.ID,
+		Session: repo.CreateSessionParams{
+			ID:           newRefreshPayload.ID,
+			Username:     session.Username,
+			RefreshToken: refreshToken,
+			UserAgent:    ctx.Request.UserAgent(),
+			ClientIp:     ctx.ClientIP(),
+			IsBlocked:    false,
+			ExpiresAt:    newRefreshPayload.ExpiredAt,
+		},
+	})
+	if err != nil {
+		// a concurrent renewal won the race for the same refresh token
+		if errors.Is(err, repo.ErrSessionRotated) {
+			s.blockSessionFamily(ctx, session)
+			return
+		}
+		respondError(ctx, err)
 		return
 	}
 
 	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
-		refreshPayload.Username,
+		user.Username,
+		user.Role,
 		s.appConfig.AccessTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
 	resp := renewAccessTokenResponse{
-		AccessToken:          accessToken,
-		AccessTokenExpiresAt: accessPayload.ExpiredAt,
+		SessionID:             newSession.ID,
+		AccessToken:           accessToken,
+		AccessTokenExpiresAt:  accessPayload.ExpiredAt,
+		RefreshToken:          refreshToken,
+		RefreshTokenExpiresAt: newRefreshPayload.ExpiredAt,
 	}
 
 	ctx.JSON(http.StatusOK, resp)
 }
+
+// blockSessionFamily revokes every session descending from the same login after a refresh token was reused
+func (s *Server) blockSessionFamily(ctx *gin.Context, session repo.Session) {
+	if _, err := s.store.BlockSessionFamily(ctx, session.FamilyID); err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	log.Warn().
+		Str("username", session.Username).
+		Str("family_id", session.FamilyID.String()).
+		Msg("refresh token reuse detected, session family blocked")
+
+	err := apperr.New(apperr.CodeSessionInvalid, "refresh token has already been used")
+	respondError(ctx, err)
+}
@@ -1,78 +1,234 @@
 package server
 
 import (
-	"fmt"
+	"math"
 	"net/http"
-
-	"github.com/simplebank/token"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/pkg/errors"
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/money"
 	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
 )
 
+type transferResponse struct {
+	ID            int64        `json:"id"`
+	FromAccountID int64        `json:"from_account_id"`
+	ToAccountID   int64        `json:"to_account_id"`
+	Amount        money.Amount `json:"amount"`
+	ToAmount      money.Amount `json:"to_amount"`
+	ExchangeRate  string       `json:"exchange_rate"`
+	// id of the transfer this one reverses
+	ReversalOf      *int64            `json:"reversal_of,omitempty"`
+	Description     string            `json:"description,omitempty"`
+	ClientReference string            `json:"client_reference,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+}
+
+func newTransferResponse(transfer repo.Transfer) transferResponse {
+	rsp := transferResponse{
+		ID:              transfer.ID,
+		FromAccountID:   transfer.FromAccountID,
+		ToAccountID:     transfer.ToAccountID,
+		Amount:          transfer.SentAmount(),
+		ToAmount:        transfer.ReceivedAmount(),
+		ExchangeRate:    transfer.ExchangeRate,
+		Description:     transfer.Description.String,
+		ClientReference: transfer.ClientReference.String,
+		Metadata:        transfer.MetadataMap(),
+		CreatedAt:       transfer.CreatedAt,
+	}
+	if transfer.ReversalOf.Valid {
+		rsp.ReversalOf = &transfer.ReversalOf.Int64
+	}
+	return rsp
+}
+
+type transferResultResponse struct {
+	Transfer    transferResponse `json:"transfer"`
+	FromAccount accountResponse  `json:"from_account"`
+	ToAccount   accountResponse  `json:"to_account"`
+	FromEntry   entryResponse    `json:"from_entry"`
+	ToEntry     entryResponse    `json:"to_entry"`
+}
+
+func newTransferResultResponse(result repo.TransferTxResult) transferResultResponse {
+	return transferResultResponse{
+		Transfer:    newTransferResponse(result.Transfer),
+		FromAccount: newAccountResponse(result.FromAccount),
+		ToAccount:   newAccountResponse(result.ToAccount),
+		FromEntry:   newEntryResponse(result.FromEntry, result.Transfer.Currency),
+		ToEntry:     newEntryResponse(result.ToEntry, result.Transfer.ToCurrency),
+	}
+}
+
 type transferRequest struct {
-	FromAccountID int64  `json:"from_account_id" binding:"required,min=1"`
-	ToAccountID   int64  `json:"to_account_id" binding:"required,min=1"`
-	Amount        int64  `json:"amount" binding:"required,gt=0"`
-	Currency      string `json:"currency" binding:"required,currency"`
+	FromAccountID   int64             `json:"from_account_id" binding:"required,min=1"`
+	ToAccountID     int64             `json:"to_account_id" binding:"required,min=1"`
+	Amount          string            `json:"amount" binding:"required"`
+	Currency        string            `json:"currency" binding:"required,currency"`
+	Description     string            `json:"description" binding:"max=255"`
+	ClientReference string            `json:"client_reference" binding:"max=64"`
+	Metadata        map[string]string `json:"metadata" binding:"max=20,dive,keys,min=1,max=40,endkeys,max=500"`
 }
 
 func (s *Server) createTransfer(ctx *gin.Context) {
 	var req transferRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		respondError(ctx, invalidRequest(err))
 		return
 	}
 
-	fromAccount, valid := s.validAccount(ctx, req.FromAccountID, req.Currency)
-	if !valid {
+	amount, err := parseAmount(req.Amount, req.Currency)
+	if err != nil {
+		respondError(ctx, err)
 		return
 	}
-	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
-	if fromAccount.Owner != authPayload.Username {
-		err := errors.New("from account doesn't belong to the authenticated user")
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+
+	result, err := s.bank.Transfer(ctx, currentActor(ctx), service.TransferParams{
+		FromAccountID:   req.FromAccountID,
+		ToAccountID:     req.ToAccountID,
+		Amount:          amount,
+		Currency:        req.Currency,
+		Description:     req.Description,
+		ClientReference: req.ClientReference,
+		Metadata:        req.Metadata,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResultResponse(result))
+}
+
+type listTransfersRequest struct {
+	Direction             string    `form:"direction" binding:"omitempty,oneof=in out"`
+	CounterpartyAccountID int64     `form:"counterparty_account_id" binding:"omitempty,min=1"`
+	Currency              string    `form:"currency" binding:"required_with=MinAmount MaxAmount,omitempty,currency"`
+	MinAmount             string    `form:"min_amount"`
+	MaxAmount             string    `form:"max_amount"`
+	StartTime             time.Time `form:"start_time" time_utc:"1"`
+	EndTime               time.Time `form:"end_time" time_utc:"1" binding:"omitempty,gtfield=StartTime"`
+	Search                string    `form:"search" binding:"max=255"`
+	ClientReference       string    `form:"client_reference" binding:"max=64"`
+	MetadataKey           string    `form:"metadata_key" binding:"required_with=MetadataValue,max=40"`
+	MetadataValue         string    `form:"metadata_value" binding:"required_with=MetadataKey,max=500"`
+	pageRequest
+}
+
+// amountRange parses the optional amount bounds into minor units of the requested currency
+func (req listTransfersRequest) amountRange() (minAmount int64, maxAmount int64, err error) {
+	if req.MinAmount != "" {
+		if minAmount, err = parseAmount(req.MinAmount, req.Currency); err != nil {
+			return 0, 0, err
+		}
+	}
+	if req.MaxAmount != "" {
+		if maxAmount, err = parseAmount(req.MaxAmount, req.Currency); err != nil {
+			return 0, 0, err
+		}
+	}
+	if minAmount != 0 && maxAmount != 0 && maxAmount < minAmount {
+		return 0, 0, apperr.New(apperr.CodeInvalidAmount, "max_amount must not be less than min_amount")
+	}
+	return minAmount, maxAmount, nil
+}
+
+type listTransfersResponse struct {
+	Transfers  []transferResponse `json:"transfers"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
+
+func (s *Server) listTransfers(ctx *gin.Context) {
+	var req listTransfersRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
 		return
 	}
 
-	_, valid = s.validAccount(ctx, req.ToAccountID, req.Currency)
-	if !valid {
+	// transfers are listed newest first, so the first page starts below the largest id
+	beforeID, err := req.position(math.MaxInt64)
+	if err != nil {
+		respondError(ctx, invalidRequest(err))
 		return
 	}
+	pageSize := req.limit()
 
-	arg := repo.TransferTxParams{
-		FromAccountID: req.FromAccountID,
-		ToAccountID:   req.ToAccountID,
-		Amount:        req.Amount,
+	minAmount, maxAmount, err := req.amountRange()
+	if err != nil {
+		respondError(ctx, err)
+		return
 	}
 
-	result, err := s.store.TransferTx(ctx, arg)
+	transfers, err := s.bank.ListTransfers(ctx, currentActor(ctx), service.ListTransfersParams{
+		Direction:             req.Direction,
+		CounterpartyAccountID: req.CounterpartyAccountID,
+		Currency:              req.Currency,
+		MinAmount:             minAmount,
+		MaxAmount:             maxAmount,
+		StartTime:             req.StartTime,
+		EndTime:               req.EndTime,
+		Search:                req.Search,
+		ClientReference:       req.ClientReference,
+		MetadataKey:           req.MetadataKey,
+		MetadataValue:         req.MetadataValue,
+		BeforeID:              beforeID,
+		// one extra row tells us whether there is another page
+		Limit: pageSize + 1,
+	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	rsp := listTransfersResponse{}
+	if len(transfers) > int(pageSize) {
+		transfers = transfers[:pageSize]
+		rsp.NextCursor = encodeCursor(transfers[pageSize-1].ID)
+	}
+
+	rsp.Transfers = make([]transferResponse, len(transfers))
+	for i, transfer := range transfers {
+		rsp.Transfers[i] = newTransferResponse(transfer)
+	}
+	ctx.JSON(http.StatusOK, rsp)
 }
 
-func (s *Server) validAccount(ctx *gin.Context, accountID int64, currency string) (repo.Account, bool) {
-	account, err := s.store.GetAccount(ctx, accountID)
+type getTransferRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (s *Server) getTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	transfer, err := s.bank.GetTransfer(ctx, currentActor(ctx), req.ID)
 	if err != nil {
-		if errors.Is(err, repo.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errResponse(err))
-			return account, false
-		}
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newTransferResponse(transfer))
+}
 
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
-		return account, false
+func (s *Server) reverseTransfer(ctx *gin.Context) {
+	var req getTransferRequest
+	if err := ctx.ShouldBindUri(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
 	}
 
-	if account.Currency != currency {
-		err := fmt.Errorf("account [%d] currency mismatch: %s vs %s", account.ID, account.Currency, currency)
-		ctx.JSON(http.StatusBadRequest, errResponse(err))
-		return account, false
+	result, err := s.bank.ReverseTransfer(ctx, currentActor(ctx), req.ID)
+	if err != nil {
+		respondError(ctx, err)
+		return
 	}
 
-	return account, true
+	ctx.JSON(http.StatusOK, newTransferResultResponse(result))
 }
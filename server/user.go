@@ -6,10 +6,15 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"gopkg.in/guregu/null.v4"
 
 	"github.com/gin-gonic/gin"
+	"github.com/simplebank/apperr"
 	"github.com/simplebank/internal/testutils"
 	"github.com/simplebank/repo"
+	"github.com/simplebank/service"
+	"github.com/simplebank/token"
+	"github.com/simplebank/worker"
 )
 
 type createUserRequest struct {
@@ -23,6 +28,9 @@ type userResponse struct {
 	Username          string    `json:"username"`
 	FullName          string    `json:"full_name"`
 	Email             string    `json:"email"`
+	PendingEmail      string    `json:"pending_email,omitempty"`
+	IsEmailVerified   bool      `json:"is_email_verified"`
+	Role              string    `json:"role"`
 	PasswordChangedAt time.Time `json:"password_changed_at"`
 	CreatedAt         time.Time `json:"created_at"`
 }
@@ -32,6 +40,9 @@ func newUserResponse(user repo.User) userResponse {
 		Username:          user.Username,
 		FullName:          user.FullName,
 		Email:             user.Email,
+		PendingEmail:      user.PendingEmail.String,
+		IsEmailVerified:   user.IsEmailVerified,
+		Role:              user.Role,
 		PasswordChangedAt: user.PasswordChangedAt,
 		CreatedAt:         user.CreatedAt,
 	}
@@ -40,30 +51,38 @@ func newUserResponse(user repo.User) userResponse {
 func (s *Server) createUser(ctx *gin.Context) {
 	var req createUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		respondError(ctx, invalidRequest(err))
 		return
 	}
 
 	hashedPassword, err := testutils.HashPassword(req.Password)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
-	arg := repo.CreateUserParams{
-		Username:       req.Username,
-		HashedPassword: hashedPassword,
-		FullName:       req.FullName,
-		Email:          req.Email,
+	arg := repo.CreateUserTxParams{
+		CreateUserParams: repo.CreateUserParams{
+			Username:       req.Username,
+			HashedPassword: hashedPassword,
+			FullName:       req.FullName,
+			Email:          req.Email,
+		},
+		AfterCreate: func(q repo.Querier, user repo.User) error {
+			return worker.EnqueueSendVerifyEmail(ctx, q, worker.PayloadSendVerifyEmail{
+				Username: user.Username,
+				Email:    user.Email,
+			})
+		},
 	}
 
-	user, err := s.store.CreateUser(ctx, arg)
+	user, err := s.store.CreateUserTx(ctx, arg)
 	if err != nil {
 		if repo.ErrorCode(err) == repo.UniqueViolation {
-			ctx.JSON(http.StatusForbidden, errResponse(err))
+			respondError(ctx, service.ErrUserAlreadyExists)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
@@ -88,41 +107,44 @@ type loginUserResponse struct {
 func (s *Server) loginUser(ctx *gin.Context) {
 	var req loginUserRequest
 	if err := ctx.ShouldBindJSON(&req); err != nil {
-		ctx.JSON(http.StatusBadRequest, errResponse(err))
+		respondError(ctx, invalidRequest(err))
 		return
 	}
 
+	// an unknown username is reported like a wrong password so logins can't be used to find out who has an account
 	user, err := s.store.GetUser(ctx, req.Username)
 	if err != nil {
 		if errors.Is(err, repo.ErrRecordNotFound) {
-			ctx.JSON(http.StatusNotFound, errResponse(err))
+			respondError(ctx, service.ErrInvalidCredentials)
 			return
 		}
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
 	err = testutils.CheckPassword(req.Password, user.HashedPassword)
 	if err != nil {
-		ctx.JSON(http.StatusUnauthorized, errResponse(err))
+		respondError(ctx, service.ErrInvalidCredentials)
 		return
 	}
 
 	accessToken, accessPayload, err := s.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		s.appConfig.AccessTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
 	refreshToken, refreshPayload, err := s.tokenMaker.CreateToken(
 		user.Username,
+		user.Role,
 		s.appConfig.RefreshTokenDuration,
 	)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 
@@ -134,9 +156,11 @@ func (s *Server) loginUser(ctx *gin.Context) {
 		ClientIp:     ctx.ClientIP(),
 		IsBlocked:    false,
 		ExpiresAt:    refreshPayload.ExpiredAt,
+		// a login starts a new family that every rotated refresh token descends from
+		FamilyID: refreshPayload.ID,
 	})
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, errResponse(err))
+		respondError(ctx, err)
 		return
 	}
 	resp := loginUserResponse{
@@ -149,3 +173,185 @@ func (s *Server) loginUser(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, resp)
 }
+
+type logoutUserRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+func (s *Server) logoutUser(ctx *gin.Context) {
+	var req logoutUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	refreshPayload, err := s.tokenMaker.VerifyToken(req.RefreshToken)
+	if err != nil {
+		respondError(ctx, service.TokenError(err))
+		return
+	}
+
+	if refreshPayload.Username != authPayload.Username {
+		err := apperr.New(apperr.CodeInvalidToken, "refresh token doesn't belong to the authenticated user")
+		respondError(ctx, err)
+		return
+	}
+
+	_, err = s.store.BlockSession(ctx, repo.BlockSessionParams{
+		ID:       refreshPayload.ID,
+		Username: authPayload.Username,
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			respondError(ctx, service.ErrSessionNotFound)
+			return
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	// the access token would otherwise stay usable until it expires
+	err = s.denylist.Revoke(ctx, authPayload.ID, authPayload.ExpiredAt)
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusNoContent)
+}
+
+func (s *Server) getCurrentUser(ctx *gin.Context) {
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	user, err := s.store.GetUser(ctx, authPayload.Username)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			respondError(ctx, errUserNotFound)
+			return
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type updateCurrentUserRequest struct {
+	FullName *string `json:"full_name" binding:"omitempty,min=1"`
+	Email    *string `json:"email" binding:"omitempty,email"`
+}
+
+func (s *Server) updateCurrentUser(ctx *gin.Context) {
+	var req updateCurrentUserRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	if req.FullName == nil && req.Email == nil {
+		err := apperr.New(apperr.CodeInvalidArgument, "at least one of full_name or email is required")
+		respondError(ctx, err)
+		return
+	}
+
+	authPayload := ctx.MustGet(authorizationPayloadKey).(*token.Payload)
+	if req.Email != nil {
+		owner, err := s.store.GetUserByEmail(ctx, *req.Email)
+		if err != nil && !errors.Is(err, repo.ErrRecordNotFound) {
+			respondError(ctx, err)
+			return
+		}
+		if err == nil && owner.Username != authPayload.Username {
+			respondError(ctx, errEmailAlreadyInUse)
+			return
+		}
+	}
+
+	arg := repo.UpdateProfileTxParams{
+		Username: authPayload.Username,
+		FullName: null.StringFromPtr(req.FullName),
+	}
+
+	// a new email is only applied once the user proves they own it
+	if req.Email != nil {
+		arg.Email = null.StringFrom(*req.Email)
+		arg.AfterUpdate = func(q repo.Querier, user repo.User) error {
+			return worker.EnqueueSendVerifyEmail(ctx, q, worker.PayloadSendVerifyEmail{
+				Username: user.Username,
+				Email:    user.PendingEmail.String,
+			})
+		}
+	}
+
+	user, err := s.store.UpdateProfileTx(ctx, arg)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			respondError(ctx, errUserNotFound)
+			return
+		}
+		if repo.ErrorCode(err) == repo.UniqueViolation {
+			respondError(ctx, errEmailAlreadyInUse)
+			return
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+type verifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+func (s *Server) verifyEmail(ctx *gin.Context) {
+	var req verifyEmailRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		respondError(ctx, invalidRequest(err))
+		return
+	}
+
+	user, err := s.store.VerifyEmailTx(ctx, repo.VerifyEmailTxParams{
+		TokenHash: token.HashSecretToken(req.Token),
+	})
+	if err != nil {
+		if errors.Is(err, repo.ErrInvalidVerifyEmail) {
+			respondError(ctx, apperr.Wrap(apperr.CodeInvalidVerificationToken, err))
+			return
+		}
+		// someone else took the address while the change was pending
+		if repo.ErrorCode(err) == repo.UniqueViolation {
+			respondError(ctx, errEmailAlreadyInUse)
+			return
+		}
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, newUserResponse(user))
+}
+
+func (s *Server) resendVerifyEmail(ctx *gin.Context) {
+	user := ctx.MustGet(authorizationUserKey).(repo.User)
+
+	// a pending change is what still needs verifying, otherwise it's the current address
+	email := user.Email
+	if user.PendingEmail.Valid {
+		email = user.PendingEmail.String
+	} else if user.IsEmailVerified {
+		err := apperr.New(apperr.CodeEmailAlreadyVerified, "email address is already verified")
+		respondError(ctx, err)
+		return
+	}
+
+	err := worker.EnqueueSendVerifyEmail(ctx, s.store, worker.PayloadSendVerifyEmail{
+		Username: user.Username,
+		Email:    email,
+	})
+	if err != nil {
+		respondError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusAccepted)
+}
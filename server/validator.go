@@ -2,12 +2,30 @@ package server
 
 import (
 	"github.com/go-playground/validator/v10"
-	"github.com/simplebank/internal/testutils"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/currency"
+	"github.com/simplebank/money"
 )
 
-var validCurrency validator.Func = func(fieldLevel validator.FieldLevel) bool {
-	if currency, ok := fieldLevel.Field().Interface().(string); ok {
-		return testutils.IsSupportedCurrency(currency)
+// validCurrency creates a validator accepting the currencies enabled in the registry
+func validCurrency(currencies *currency.Registry) validator.Func {
+	return func(fieldLevel validator.FieldLevel) bool {
+		if code, ok := fieldLevel.Field().Interface().(string); ok {
+			return currencies.IsSupported(code)
+		}
+		return false
+	}
+}
+
+// parseAmount reads a positive decimal request amount such as "12.34" into minor units of currencyCode
+func parseAmount(value string, currencyCode string) (int64, error) {
+	amount, err := money.Parse(value, currencyCode)
+	if err != nil {
+		return 0, apperr.Wrap(apperr.CodeInvalidAmount, err)
+	}
+	if amount.Minor() <= 0 {
+		return 0, apperr.New(apperr.CodeInvalidAmount, "amount must be positive")
 	}
-	return false
+	return amount.Minor(), nil
 }
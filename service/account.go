@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/repo"
+)
+
+// CreateAccount opens an empty account in currency for the actor
+func (bank *Bank) CreateAccount(ctx context.Context, actor Actor, currency string) (repo.Account, error) {
+	if !bank.currencies.IsSupported(currency) {
+		return repo.Account{}, fmt.Errorf("%w: %s", ErrUnsupportedCurrency, currency)
+	}
+
+	return bank.store.CreateAccount(ctx, repo.CreateAccountParams{
+		Owner:    actor.Username,
+		Balance:  0,
+		Currency: currency,
+	})
+}
+
+// GetAccount returns one of the actor's accounts
+func (bank *Bank) GetAccount(ctx context.Context, actor Actor, accountID int64) (repo.Account, error) {
+	account, err := bank.fetchAccount(ctx, accountID)
+	if err != nil {
+		return account, err
+	}
+
+	if account.Owner != actor.Username {
+		return repo.Account{}, ErrAccountNotOwned
+	}
+	return account, nil
+}
+
+// ListAccountsParams selects a page of the actor's accounts
+type ListAccountsParams struct {
+	AfterID int64
+	Limit   int32
+}
+
+// ListAccounts lists the actor's accounts ordered by id
+func (bank *Bank) ListAccounts(ctx context.Context, actor Actor, arg ListAccountsParams) ([]repo.Account, error) {
+	return bank.store.ListAccountsAfter(ctx, repo.ListAccountsAfterParams{
+		Owner:     actor.Username,
+		AfterID:   arg.AfterID,
+		PageLimit: arg.Limit,
+	})
+}
+
+// AccountStatementParams selects a page of an account's entries, the time window is optional
+type AccountStatementParams struct {
+	AccountID int64
+	AfterID   int64
+	Limit     int32
+	FromTime  time.Time
+	ToTime    time.Time
+}
+
+// AccountStatement lists the entries of one of the actor's accounts with the running balance
+func (bank *Bank) AccountStatement(ctx context.Context, actor Actor, arg AccountStatementParams) (repo.Account, []repo.ListAccountStatementRow, error) {
+	account, err := bank.GetAccount(ctx, actor, arg.AccountID)
+	if err != nil {
+		return account, nil, err
+	}
+
+	params := repo.ListAccountStatementParams{
+		AccountID: account.ID,
+		AfterID:   arg.AfterID,
+		PageLimit: arg.Limit,
+	}
+	if !arg.FromTime.IsZero() {
+		params.FromTime = null.TimeFrom(arg.FromTime)
+	}
+	if !arg.ToTime.IsZero() {
+		params.ToTime = null.TimeFrom(arg.ToTime)
+	}
+
+	entries, err := bank.store.ListAccountStatement(ctx, params)
+	if err != nil {
+		return account, nil, err
+	}
+	return account, entries, nil
+}
+
+// UpdateAccountStatus moves an account through its lifecycle. Owners can freeze and close their own accounts,
+// unfreezing and reopening is left to staff, who can change the status of any account.
+func (bank *Bank) UpdateAccountStatus(ctx context.Context, actor Actor, accountID int64, status string) (repo.Account, error) {
+	if !actor.IsStaff() {
+		if status == repo.AccountStatusActive {
+			return repo.Account{}, ErrStatusChangeNotAllowed
+		}
+		if _, err := bank.GetAccount(ctx, actor, accountID); err != nil {
+			return repo.Account{}, err
+		}
+	}
+
+	account, err := bank.store.UpdateAccountStatusTx(ctx, repo.UpdateAccountStatusTxParams{
+		AccountID: accountID,
+		Status:    status,
+	})
+	switch {
+	case errors.Is(err, repo.ErrRecordNotFound):
+		return account, fmt.Errorf("account [%d]: %w", accountID, ErrAccountNotFound)
+	case errors.Is(err, repo.ErrInvalidStatusTransition):
+		return account, fmt.Errorf("account [%d] %w to %s", accountID, ErrInvalidStatusTransition, status)
+	case errors.Is(err, repo.ErrAccountNotEmpty):
+		return account, fmt.Errorf("account [%d]: %w", accountID, ErrAccountNotEmpty)
+	}
+	return account, err
+}
+
+func (bank *Bank) fetchAccount(ctx context.Context, accountID int64) (repo.Account, error) {
+	account, err := bank.store.GetAccount(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return account, fmt.Errorf("account [%d]: %w", accountID, ErrAccountNotFound)
+		}
+		return account, err
+	}
+	return account, nil
+}
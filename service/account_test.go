@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func TestCreateAccount(t *testing.T) {
+	bank, store := newTestBank(t)
+	actor := randomActor()
+	account := randomAccount(actor.Username, testutils.USD)
+
+	arg := repo.CreateAccountParams{
+		Owner:    actor.Username,
+		Balance:  0,
+		Currency: testutils.USD,
+	}
+	store.EXPECT().CreateAccount(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+
+	created, err := bank.CreateAccount(context.Background(), actor, testutils.USD)
+	require.NoError(t, err)
+	require.Equal(t, account, created)
+}
+
+func TestCreateAccountUnsupportedCurrency(t *testing.T) {
+	bank, store := newTestBank(t)
+	store.EXPECT().CreateAccount(gomock.Any(), gomock.Any()).Times(0)
+
+	_, err := bank.CreateAccount(context.Background(), randomActor(), "XYZ")
+	require.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
+
+func TestGetAccount(t *testing.T) {
+	actor := randomActor()
+	account := randomAccount(actor.Username, testutils.USD)
+
+	testCases := []struct {
+		name       string
+		actor      Actor
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:  "OK",
+			actor: actor,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "NotFound",
+			actor: actor,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotFound)
+			},
+		},
+		{
+			name:  "NotOwned",
+			actor: randomActor(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotOwned)
+			},
+		},
+		{
+			name:  "InternalError",
+			actor: actor,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(repo.Account{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			got, err := bank.GetAccount(context.Background(), tc.actor, account.ID)
+			tc.checkError(t, err)
+			if err == nil {
+				require.Equal(t, account, got)
+			}
+		})
+	}
+}
+
+func TestUpdateAccountStatus(t *testing.T) {
+	actor := randomActor()
+	staff := randomActor()
+	staff.Role = token.RoleTeller
+	account := randomAccount(actor.Username, testutils.USD)
+
+	testCases := []struct {
+		name       string
+		actor      Actor
+		status     string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:   "Freeze",
+			actor:  actor,
+			status: repo.AccountStatusFrozen,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				arg := repo.UpdateAccountStatusTxParams{AccountID: account.ID, Status: repo.AccountStatusFrozen}
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "CustomerReactivates",
+			actor:  actor,
+			status: repo.AccountStatusActive,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrStatusChangeNotAllowed)
+			},
+		},
+		{
+			name:   "NotOwned",
+			actor:  randomActor(),
+			status: repo.AccountStatusClosed,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotOwned)
+			},
+		},
+		{
+			name:   "StaffReactivates",
+			actor:  staff,
+			status: repo.AccountStatusActive,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				arg := repo.UpdateAccountStatusTxParams{AccountID: account.ID, Status: repo.AccountStatusActive}
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Eq(arg)).Times(1).Return(account, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:   "NotEmpty",
+			actor:  actor,
+			status: repo.AccountStatusClosed,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.Account{}, repo.ErrAccountNotEmpty)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotEmpty)
+			},
+		},
+		{
+			name:   "InvalidTransition",
+			actor:  staff,
+			status: repo.AccountStatusFrozen,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.Account{}, repo.ErrInvalidStatusTransition)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidStatusTransition)
+			},
+		},
+		{
+			name:   "NotFound",
+			actor:  staff,
+			status: repo.AccountStatusFrozen,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateAccountStatusTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			_, err := bank.UpdateAccountStatus(context.Background(), tc.actor, account.ID, tc.status)
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestListAccounts(t *testing.T) {
+	bank, store := newTestBank(t)
+	actor := randomActor()
+	accounts := []repo.Account{randomAccount(actor.Username, testutils.USD)}
+
+	arg := repo.ListAccountsAfterParams{
+		Owner:     actor.Username,
+		AfterID:   5,
+		PageLimit: 10,
+	}
+	store.EXPECT().ListAccountsAfter(gomock.Any(), gomock.Eq(arg)).Times(1).Return(accounts, nil)
+
+	got, err := bank.ListAccounts(context.Background(), actor, ListAccountsParams{AfterID: 5, Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, accounts, got)
+}
+
+func TestAccountStatement(t *testing.T) {
+	actor := randomActor()
+	account := randomAccount(actor.Username, testutils.USD)
+	fromTime := time.Now().Add(-time.Hour)
+
+	t.Run("OK", func(t *testing.T) {
+		bank, store := newTestBank(t)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+
+		arg := repo.ListAccountStatementParams{
+			AccountID: account.ID,
+			PageLimit: 10,
+			FromTime:  null.TimeFrom(fromTime),
+		}
+		rows := []repo.ListAccountStatementRow{{ID: 1, AccountID: account.ID}}
+		store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Eq(arg)).Times(1).Return(rows, nil)
+
+		gotAccount, gotRows, err := bank.AccountStatement(context.Background(), actor, AccountStatementParams{
+			AccountID: account.ID,
+			Limit:     10,
+			FromTime:  fromTime,
+		})
+		require.NoError(t, err)
+		require.Equal(t, account, gotAccount)
+		require.Equal(t, rows, gotRows)
+	})
+
+	t.Run("NotOwned", func(t *testing.T) {
+		bank, store := newTestBank(t)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+		store.EXPECT().ListAccountStatement(gomock.Any(), gomock.Any()).Times(0)
+
+		_, _, err := bank.AccountStatement(context.Background(), randomActor(), AccountStatementParams{AccountID: account.ID})
+		require.ErrorIs(t, err, ErrAccountNotOwned)
+	})
+}
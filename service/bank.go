@@ -0,0 +1,36 @@
+package service
+
+import (
+	"github.com/simplebank/currency"
+	"github.com/simplebank/exchange"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
+)
+
+// Actor is whoever performs an operation, transports build it from the authenticated user
+type Actor struct {
+	Username      string
+	Role          string
+	EmailVerified bool
+}
+
+// IsStaff returns true for tellers and admins, who can act on accounts they don't own
+func (actor Actor) IsStaff() bool {
+	return actor.Role == token.RoleTeller || actor.Role == token.RoleAdmin
+}
+
+// Bank owns the banking rules on top of repo.Store, independent of how requests arrive
+type Bank struct {
+	store      repo.Store
+	fxRates    exchange.FXRateProvider
+	currencies *currency.Registry
+}
+
+// NewBank creates a new Bank
+func NewBank(store repo.Store, fxRates exchange.FXRateProvider, currencies *currency.Registry) *Bank {
+	return &Bank{
+		store:      store,
+		fxRates:    fxRates,
+		currencies: currencies,
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"github.com/pkg/errors"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/token"
+)
+
+var (
+	// ErrAccountNotFound is returned when an account id doesn't exist
+	ErrAccountNotFound = apperr.New(apperr.CodeAccountNotFound, "account not found")
+
+	// ErrTransferNotFound is returned when a transfer id doesn't exist
+	ErrTransferNotFound = apperr.New(apperr.CodeTransferNotFound, "transfer not found")
+
+	// ErrAccountNotOwned is returned when the actor operates on someone else's account
+	ErrAccountNotOwned = apperr.New(apperr.CodeAccountNotOwned, "account doesn't belong to the authenticated user")
+
+	// ErrTransferNotOwned is returned when the actor owns neither side of a transfer
+	ErrTransferNotOwned = apperr.New(apperr.CodeTransferNotOwned, "transfer doesn't belong to the authenticated user")
+
+	// ErrCurrencyMismatch is returned when the requested currency isn't the account's currency
+	ErrCurrencyMismatch = apperr.New(apperr.CodeCurrencyMismatch, "currency mismatch")
+
+	// ErrUnsupportedCurrency is returned for currencies the bank doesn't hold accounts in
+	ErrUnsupportedCurrency = apperr.New(apperr.CodeUnsupportedCurrency, "unsupported currency")
+
+	// ErrInvalidAmount is returned for amounts that can't be moved, such as zero or ones too small to convert
+	ErrInvalidAmount = apperr.New(apperr.CodeInvalidAmount, "invalid amount")
+
+	// ErrEmailNotVerified is returned when an operation needs a verified email address
+	ErrEmailNotVerified = apperr.New(apperr.CodeEmailNotVerified, "email address has not been verified")
+
+	// ErrInsufficientFunds is returned when a debit would take an account below its overdraft limit
+	ErrInsufficientFunds = apperr.New(apperr.CodeInsufficientFunds, "insufficient funds")
+
+	// ErrAccountInactive is returned when a transfer touches a frozen or closed account
+	ErrAccountInactive = apperr.New(apperr.CodeAccountInactive, "account is not active")
+
+	// ErrAccountNotEmpty is returned when closing an account whose balance isn't zero
+	ErrAccountNotEmpty = apperr.New(apperr.CodeAccountNotEmpty, "account balance must be zero to close it")
+
+	// ErrInvalidStatusTransition is returned when an account can't move from its current status to the requested one
+	ErrInvalidStatusTransition = apperr.New(apperr.CodeInvalidStatusTransition, "invalid account status transition")
+
+	// ErrStatusChangeNotAllowed is returned when a customer tries to reactivate an account, only staff can
+	ErrStatusChangeNotAllowed = apperr.New(apperr.CodePermissionDenied, "only staff can reactivate an account")
+
+	// ErrStaffOnly is returned when a customer attempts an operation reserved to tellers and admins
+	ErrStaffOnly = apperr.New(apperr.CodePermissionDenied, "only staff can record deposits and withdrawals")
+
+	// ErrExternalTransferNotFound is returned when a deposit or withdrawal id doesn't exist for the account
+	ErrExternalTransferNotFound = apperr.New(apperr.CodeExternalTransferNotFound, "deposit or withdrawal not found")
+
+	// ErrDuplicateReference is returned when an external reference was already recorded for the same kind of operation
+	ErrDuplicateReference = apperr.New(apperr.CodeDuplicateReference, "external reference has already been recorded")
+
+	// ErrAlreadySettled is returned when settling or failing a deposit or withdrawal that was already confirmed
+	ErrAlreadySettled = apperr.New(apperr.CodeAlreadySettled, "already settled")
+
+	// ErrAlreadyFailed is returned when settling or failing a deposit or withdrawal that was already rejected
+	ErrAlreadyFailed = apperr.New(apperr.CodeAlreadyFailed, "already failed")
+
+	// ErrScheduledTransferNotFound is returned when a scheduled transfer id doesn't exist for the actor
+	ErrScheduledTransferNotFound = apperr.New(apperr.CodeScheduledTransferNotFound, "scheduled transfer not found")
+
+	// ErrScheduledTransferFinished is returned when changing a scheduled transfer that was completed or cancelled
+	ErrScheduledTransferFinished = apperr.New(apperr.CodeScheduledTransferFinished, "scheduled transfer is completed or cancelled")
+
+	// ErrInvalidSchedule is returned for schedules that can't be parsed, run too often or start in the past
+	ErrInvalidSchedule = apperr.New(apperr.CodeInvalidSchedule, "invalid schedule")
+
+	// ErrTransferAlreadyReversed is returned when reversing a transfer that was already given back
+	ErrTransferAlreadyReversed = apperr.New(apperr.CodeTransferNotReversible, "transfer has already been reversed")
+
+	// ErrReversalNotReversible is returned when reversing a transfer that is itself a reversal
+	ErrReversalNotReversible = apperr.New(apperr.CodeTransferNotReversible, "a reversal can't be reversed")
+
+	// ErrReverseNotAllowed is returned when someone other than the recipient or an admin reverses a transfer
+	ErrReverseNotAllowed = apperr.New(apperr.CodePermissionDenied, "only the recipient or an admin can reverse a transfer")
+
+	// ErrRateNotFound is returned when no exchange rate is known for a currency pair
+	ErrRateNotFound = apperr.New(apperr.CodeExchangeRateNotFound, "exchange rate not found")
+
+	// ErrRevokedToken is returned for tokens that verify but were revoked afterwards
+	ErrRevokedToken = apperr.Wrap(apperr.CodeTokenRevoked, token.ErrRevokedToken)
+
+	// ErrInvalidCredentials doesn't say whether the username or the password was wrong
+	ErrInvalidCredentials = apperr.New(apperr.CodeInvalidCredentials, "invalid username or password")
+
+	// ErrUserAlreadyExists is returned when the username or email of a new user is taken
+	ErrUserAlreadyExists = apperr.New(apperr.CodeUserAlreadyExists, "username or email is already taken")
+
+	// ErrSessionNotFound is returned when a session id doesn't exist
+	ErrSessionNotFound = apperr.New(apperr.CodeSessionNotFound, "session not found")
+)
+
+// TokenError tags errors returned when verifying a token
+func TokenError(err error) error {
+	if errors.Is(err, token.ErrExpiredToken) {
+		return apperr.Wrap(apperr.CodeTokenExpired, err)
+	}
+	return apperr.Wrap(apperr.CodeInvalidToken, token.ErrInvalidToken)
+}
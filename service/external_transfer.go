@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/simplebank/repo"
+)
+
+// ExternalTransferParams describes a deposit or withdrawal recorded by staff, Currency must be the account's currency
+type ExternalTransferParams struct {
+	AccountID         int64
+	Amount            int64
+	Currency          string
+	ExternalReference string
+	// Settled records the operation as already confirmed, such as cash handed over at the counter
+	Settled bool
+}
+
+// Deposit credits an account with money coming from outside the bank
+func (bank *Bank) Deposit(ctx context.Context, actor Actor, arg ExternalTransferParams) (repo.ExternalTransferTxResult, error) {
+	return bank.externalTransfer(ctx, actor, repo.ExternalTransferDeposit, arg)
+}
+
+// Withdraw debits an account with money leaving the bank, within the account's overdraft limit
+func (bank *Bank) Withdraw(ctx context.Context, actor Actor, arg ExternalTransferParams) (repo.ExternalTransferTxResult, error) {
+	return bank.externalTransfer(ctx, actor, repo.ExternalTransferWithdrawal, arg)
+}
+
+func (bank *Bank) externalTransfer(ctx context.Context, actor Actor, kind string, arg ExternalTransferParams) (repo.ExternalTransferTxResult, error) {
+	if !actor.IsStaff() {
+		return repo.ExternalTransferTxResult{}, ErrStaffOnly
+	}
+	if arg.Amount <= 0 {
+		return repo.ExternalTransferTxResult{}, fmt.Errorf("%w: must be positive", ErrInvalidAmount)
+	}
+
+	account, err := bank.fetchAccount(ctx, arg.AccountID)
+	if err != nil {
+		return repo.ExternalTransferTxResult{}, err
+	}
+	// clearing accounts are the other side of every deposit and withdrawal, they can't be one themselves
+	if account.IsClearing() {
+		return repo.ExternalTransferTxResult{}, fmt.Errorf("account [%d]: %w", account.ID, ErrAccountNotFound)
+	}
+	if account.Currency != arg.Currency {
+		return repo.ExternalTransferTxResult{}, fmt.Errorf("account [%d] %w: %s vs %s", account.ID, ErrCurrencyMismatch, account.Currency, arg.Currency)
+	}
+	if !account.IsActive() {
+		return repo.ExternalTransferTxResult{}, fmt.Errorf("account [%d] is %s: %w", account.ID, account.Status, ErrAccountInactive)
+	}
+
+	result, err := bank.store.ExternalTransferTx(ctx, repo.ExternalTransferTxParams{
+		Kind:              kind,
+		AccountID:         account.ID,
+		Amount:            arg.Amount,
+		ExternalReference: arg.ExternalReference,
+		Settled:           arg.Settled,
+		CreatedBy:         actor.Username,
+	})
+	switch {
+	case repo.ErrorCode(err) == repo.UniqueViolation:
+		return result, fmt.Errorf("%s %q: %w", kind, arg.ExternalReference, ErrDuplicateReference)
+	case errors.Is(err, repo.ErrInsufficientFunds):
+		return result, fmt.Errorf("account [%d]: %w", account.ID, ErrInsufficientFunds)
+	case errors.Is(err, repo.ErrAccountInactive):
+		// the account was frozen or closed after it was read above
+		return result, ErrAccountInactive
+	}
+	return result, err
+}
+
+// SettleExternalTransfer marks a pending deposit or withdrawal of an account as confirmed by the external system,
+// a deposit only reaches the account's balance now
+func (bank *Bank) SettleExternalTransfer(ctx context.Context, actor Actor, accountID int64, kind string, id int64) (repo.ExternalTransferTxResult, error) {
+	if err := bank.checkPendingExternalTransfer(ctx, actor, accountID, kind, id); err != nil {
+		return repo.ExternalTransferTxResult{}, err
+	}
+
+	result, err := bank.store.SettleExternalTransferTx(ctx, id)
+	switch {
+	case errors.Is(err, repo.ErrExternalTransferNotPending):
+		// settled or failed concurrently
+		return result, notPendingError(result.ExternalTransfer)
+	case errors.Is(err, repo.ErrAccountInactive):
+		return result, fmt.Errorf("account [%d] is %s: %w", accountID, result.Account.Status, ErrAccountInactive)
+	}
+	return result, err
+}
+
+// FailExternalTransfer marks a pending deposit or withdrawal of an account as rejected by the external system,
+// a withdrawal gives the money it held back to the account
+func (bank *Bank) FailExternalTransfer(ctx context.Context, actor Actor, accountID int64, kind string, id int64) (repo.ExternalTransferTxResult, error) {
+	if err := bank.checkPendingExternalTransfer(ctx, actor, accountID, kind, id); err != nil {
+		return repo.ExternalTransferTxResult{}, err
+	}
+
+	result, err := bank.store.FailExternalTransferTx(ctx, id)
+	if errors.Is(err, repo.ErrExternalTransferNotPending) {
+		// settled or failed concurrently
+		return result, notPendingError(result.ExternalTransfer)
+	}
+	return result, err
+}
+
+// checkPendingExternalTransfer makes sure staff act on a pending deposit or withdrawal of the given account and kind
+func (bank *Bank) checkPendingExternalTransfer(ctx context.Context, actor Actor, accountID int64, kind string, id int64) error {
+	if !actor.IsStaff() {
+		return ErrStaffOnly
+	}
+
+	transfer, err := bank.store.GetExternalTransfer(ctx, id)
+	if err != nil && !errors.Is(err, repo.ErrRecordNotFound) {
+		return err
+	}
+	// ids of another account or kind are reported the same as unknown ones
+	if err != nil || transfer.AccountID != accountID || transfer.Kind != kind {
+		return fmt.Errorf("%s [%d]: %w", kind, id, ErrExternalTransferNotFound)
+	}
+	if transfer.Status != repo.ExternalTransferPending {
+		return notPendingError(transfer)
+	}
+	return nil
+}
+
+func notPendingError(transfer repo.ExternalTransfer) error {
+	if transfer.Status == repo.ExternalTransferFailed {
+		return fmt.Errorf("%s [%d]: %w", transfer.Kind, transfer.ID, ErrAlreadyFailed)
+	}
+	return fmt.Errorf("%s [%d]: %w", transfer.Kind, transfer.ID, ErrAlreadySettled)
+}
@@ -0,0 +1,473 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func TestDeposit(t *testing.T) {
+	teller := randomActor()
+	teller.Role = token.RoleTeller
+
+	account := randomAccount(testutils.RandomOwner(), testutils.USD)
+	frozenAccount := randomAccount(account.Owner, testutils.EUR)
+	frozenAccount.Status = repo.AccountStatusFrozen
+	clearingAccount := randomAccount(repo.ClearingAccountOwner, testutils.USD)
+
+	arg := ExternalTransferParams{
+		AccountID:         account.ID,
+		Amount:            100,
+		Currency:          testutils.USD,
+		ExternalReference: testutils.RandomString(12),
+		Settled:           true,
+	}
+
+	testCases := []struct {
+		name       string
+		actor      Actor
+		arg        func() ExternalTransferParams
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:  "OK",
+			actor: teller,
+			arg:   func() ExternalTransferParams { return arg },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				txArg := repo.ExternalTransferTxParams{
+					Kind:              repo.ExternalTransferDeposit,
+					AccountID:         account.ID,
+					Amount:            arg.Amount,
+					ExternalReference: arg.ExternalReference,
+					Settled:           true,
+					CreatedBy:         teller.Username,
+				}
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Eq(txArg)).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "Customer",
+			actor: randomActor(),
+			arg:   func() ExternalTransferParams { return arg },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrStaffOnly)
+			},
+		},
+		{
+			name:  "InvalidAmount",
+			actor: teller,
+			arg: func() ExternalTransferParams {
+				invalid := arg
+				invalid.Amount = 0
+				return invalid
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidAmount)
+			},
+		},
+		{
+			name:  "CurrencyMismatch",
+			actor: teller,
+			arg: func() ExternalTransferParams {
+				mismatch := arg
+				mismatch.Currency = testutils.EUR
+				return mismatch
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrCurrencyMismatch)
+			},
+		},
+		{
+			name:  "ClearingAccount",
+			actor: teller,
+			arg: func() ExternalTransferParams {
+				clearing := arg
+				clearing.AccountID = clearingAccount.ID
+				return clearing
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(clearingAccount.ID)).Times(1).Return(clearingAccount, nil)
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotFound)
+			},
+		},
+		{
+			name:  "InactiveAccount",
+			actor: teller,
+			arg: func() ExternalTransferParams {
+				frozen := arg
+				frozen.AccountID, frozen.Currency = frozenAccount.ID, testutils.EUR
+				return frozen
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(frozenAccount.ID)).Times(1).Return(frozenAccount, nil)
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountInactive)
+			},
+		},
+		{
+			name:  "DuplicateReference",
+			actor: teller,
+			arg:   func() ExternalTransferParams { return arg },
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+				store.EXPECT().ExternalTransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.ExternalTransferTxResult{}, repo.ErrUniqueViolation)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrDuplicateReference)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			_, err := bank.Deposit(context.Background(), tc.actor, tc.arg())
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestWithdrawInsufficientFunds(t *testing.T) {
+	teller := randomActor()
+	teller.Role = token.RoleTeller
+	account := randomAccount(testutils.RandomOwner(), testutils.USD)
+
+	bank, store := newTestBank(t)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(account.ID)).Times(1).Return(account, nil)
+	store.EXPECT().
+		ExternalTransferTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg repo.ExternalTransferTxParams) (repo.ExternalTransferTxResult, error) {
+			require.Equal(t, repo.ExternalTransferWithdrawal, arg.Kind)
+			require.False(t, arg.Settled)
+			return repo.ExternalTransferTxResult{}, repo.ErrInsufficientFunds
+		})
+
+	_, err := bank.Withdraw(context.Background(), teller, ExternalTransferParams{
+		AccountID:         account.ID,
+		Amount:            account.Balance + 1,
+		Currency:          testutils.USD,
+		ExternalReference: testutils.RandomString(12),
+	})
+	require.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestSettleExternalTransfer(t *testing.T) {
+	teller := randomActor()
+	teller.Role = token.RoleTeller
+
+	pending := repo.ExternalTransfer{
+		ID:        testutils.RandomInt(1, 1000),
+		Kind:      repo.ExternalTransferDeposit,
+		AccountID: testutils.RandomInt(1, 1000),
+		Amount:    100,
+		Currency:  testutils.USD,
+		Status:    repo.ExternalTransferPending,
+	}
+	settled := pending
+	settled.Status = repo.ExternalTransferSettled
+	failed := pending
+	failed.Status = repo.ExternalTransferFailed
+
+	testCases := []struct {
+		name       string
+		accountID  int64
+		kind       string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:      "OK",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().SettleExternalTransferTx(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(repo.ExternalTransferTxResult{ExternalTransfer: settled}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "OtherAccount",
+			accountID: pending.AccountID + 1,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().SettleExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrExternalTransferNotFound)
+			},
+		},
+		{
+			name:      "OtherKind",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferWithdrawal,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().SettleExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrExternalTransferNotFound)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(repo.ExternalTransfer{}, repo.ErrRecordNotFound)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrExternalTransferNotFound)
+			},
+		},
+		{
+			name:      "AlreadySettled",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(settled, nil)
+				store.EXPECT().SettleExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAlreadySettled)
+			},
+		},
+		{
+			name:      "AlreadyFailed",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(failed, nil)
+				store.EXPECT().SettleExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAlreadyFailed)
+			},
+		},
+		{
+			name:      "FailedConcurrently",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().
+					SettleExternalTransferTx(gomock.Any(), gomock.Eq(pending.ID)).
+					Times(1).
+					Return(repo.ExternalTransferTxResult{ExternalTransfer: failed}, repo.ErrExternalTransferNotPending)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAlreadyFailed)
+			},
+		},
+		{
+			name:      "AccountInactive",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().
+					SettleExternalTransferTx(gomock.Any(), gomock.Eq(pending.ID)).
+					Times(1).
+					Return(repo.ExternalTransferTxResult{}, repo.ErrAccountInactive)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountInactive)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(repo.ExternalTransfer{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			_, err := bank.SettleExternalTransfer(context.Background(), teller, tc.accountID, tc.kind, pending.ID)
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestFailExternalTransfer(t *testing.T) {
+	teller := randomActor()
+	teller.Role = token.RoleTeller
+
+	pending := repo.ExternalTransfer{
+		ID:        testutils.RandomInt(1, 1000),
+		Kind:      repo.ExternalTransferDeposit,
+		AccountID: testutils.RandomInt(1, 1000),
+		Amount:    100,
+		Currency:  testutils.USD,
+		Status:    repo.ExternalTransferPending,
+	}
+	settled := pending
+	settled.Status = repo.ExternalTransferSettled
+	failed := pending
+	failed.Status = repo.ExternalTransferFailed
+
+	testCases := []struct {
+		name       string
+		accountID  int64
+		kind       string
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:      "OK",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().FailExternalTransferTx(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(repo.ExternalTransferTxResult{ExternalTransfer: failed}, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "OtherAccount",
+			accountID: pending.AccountID + 1,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().FailExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrExternalTransferNotFound)
+			},
+		},
+		{
+			name:      "OtherKind",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferWithdrawal,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().FailExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrExternalTransferNotFound)
+			},
+		},
+		{
+			name:      "NotFound",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(repo.ExternalTransfer{}, repo.ErrRecordNotFound)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrExternalTransferNotFound)
+			},
+		},
+		{
+			name:      "AlreadySettled",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(settled, nil)
+				store.EXPECT().FailExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAlreadySettled)
+			},
+		},
+		{
+			name:      "AlreadyFailed",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(failed, nil)
+				store.EXPECT().FailExternalTransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAlreadyFailed)
+			},
+		},
+		{
+			name:      "FailedConcurrently",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(pending, nil)
+				store.EXPECT().
+					FailExternalTransferTx(gomock.Any(), gomock.Eq(pending.ID)).
+					Times(1).
+					Return(repo.ExternalTransferTxResult{ExternalTransfer: failed}, repo.ErrExternalTransferNotPending)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAlreadyFailed)
+			},
+		},
+		{
+			name:      "InternalError",
+			accountID: pending.AccountID,
+			kind:      repo.ExternalTransferDeposit,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetExternalTransfer(gomock.Any(), gomock.Eq(pending.ID)).Times(1).Return(repo.ExternalTransfer{}, sql.ErrConnDone)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			_, err := bank.FailExternalTransfer(context.Background(), teller, tc.accountID, tc.kind, pending.ID)
+			tc.checkError(t, err)
+		})
+	}
+}
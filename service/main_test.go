@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/currency"
+	"github.com/simplebank/exchange"
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+)
+
+func newTestBank(t *testing.T) (*Bank, *mockdb.MockStore) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	store := mockdb.NewMockStore(ctrl)
+	fxRates, err := exchange.NewStaticRateProvider(map[string]string{"USD/EUR": "0.92"})
+	require.NoError(t, err)
+
+	currencies, err := currency.NewRegistry([]string{testutils.USD, testutils.EUR, testutils.CAD})
+	require.NoError(t, err)
+
+	return NewBank(store, fxRates, currencies), store
+}
+
+func randomActor() Actor {
+	return Actor{
+		Username:      testutils.RandomOwner(),
+		Role:          token.RoleCustomer,
+		EmailVerified: true,
+	}
+}
+
+func randomAccount(owner string, currency string) repo.Account {
+	return repo.Account{
+		ID:       testutils.RandomInt(1, 1000),
+		Owner:    owner,
+		Balance:  testutils.RandomMoney(),
+		Currency: currency,
+		Status:   repo.AccountStatusActive,
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/apperr"
+	"github.com/simplebank/repo"
+)
+
+// minScheduleInterval is the shortest time allowed between two occurrences of a recurring transfer
+const minScheduleInterval = time.Minute
+
+// ErrOccurrenceHandled is returned when the due occurrence of a scheduled transfer was executed by another scheduler,
+// or paused or rescheduled by its owner, before it could be recorded. Nothing was written.
+var ErrOccurrenceHandled = errors.New("scheduled transfer occurrence was already handled")
+
+// CreateScheduledTransferParams describes a transfer to make later, once or on a schedule
+type CreateScheduledTransferParams struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	Currency      string
+	// Schedule is a cron expression or a descriptor such as @monthly or @every 24h, empty for a one-off transfer
+	Schedule string
+	// StartAt is the first occurrence, later ones follow the schedule from there.
+	// It is required for one-off transfers, recurring ones default to the schedule's next occurrence.
+	StartAt time.Time
+}
+
+// CreateScheduledTransfer schedules a transfer out of one of the actor's accounts.
+// It is checked as if it ran now, then again every time an occurrence is executed.
+func (bank *Bank) CreateScheduledTransfer(ctx context.Context, actor Actor, arg CreateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+	if !actor.EmailVerified {
+		return repo.ScheduledTransfer{}, ErrEmailNotVerified
+	}
+
+	_, err := bank.prepareTransfer(ctx, actor.Username, TransferParams{
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+	})
+	if err != nil {
+		return repo.ScheduledTransfer{}, err
+	}
+
+	firstRunAt, err := firstOccurrence(arg.Schedule, arg.StartAt, time.Now())
+	if err != nil {
+		return repo.ScheduledTransfer{}, err
+	}
+
+	return bank.store.CreateScheduledTransfer(ctx, repo.CreateScheduledTransferParams{
+		Owner:         actor.Username,
+		FromAccountID: arg.FromAccountID,
+		ToAccountID:   arg.ToAccountID,
+		Amount:        arg.Amount,
+		Currency:      arg.Currency,
+		Schedule:      null.NewString(arg.Schedule, arg.Schedule != ""),
+		NextRunAt:     null.TimeFrom(firstRunAt),
+	})
+}
+
+// GetScheduledTransfer returns one of the actor's scheduled transfers
+func (bank *Bank) GetScheduledTransfer(ctx context.Context, actor Actor, id int64) (repo.ScheduledTransfer, error) {
+	scheduled, err := bank.store.GetScheduledTransfer(ctx, id)
+	if err != nil && !errors.Is(err, repo.ErrRecordNotFound) {
+		return scheduled, err
+	}
+	// other users' scheduled transfers are reported the same as unknown ones
+	if err != nil || scheduled.Owner != actor.Username {
+		return repo.ScheduledTransfer{}, fmt.Errorf("scheduled transfer [%d]: %w", id, ErrScheduledTransferNotFound)
+	}
+	return scheduled, nil
+}
+
+// ListScheduledTransfersParams selects a page of the actor's scheduled transfers
+type ListScheduledTransfersParams struct {
+	AfterID int64
+	Limit   int32
+}
+
+// ListScheduledTransfers lists the actor's scheduled transfers ordered by id, finished ones included
+func (bank *Bank) ListScheduledTransfers(ctx context.Context, actor Actor, arg ListScheduledTransfersParams) ([]repo.ScheduledTransfer, error) {
+	return bank.store.ListScheduledTransfersAfter(ctx, repo.ListScheduledTransfersAfterParams{
+		Owner:     actor.Username,
+		AfterID:   arg.AfterID,
+		PageLimit: arg.Limit,
+	})
+}
+
+// ListScheduledTransferRunsParams selects a page of the executed occurrences of a scheduled transfer
+type ListScheduledTransferRunsParams struct {
+	ScheduledTransferID int64
+	AfterID             int64
+	Limit               int32
+}
+
+// ListScheduledTransferRuns lists the executed occurrences of one of the actor's scheduled transfers, oldest first
+func (bank *Bank) ListScheduledTransferRuns(ctx context.Context, actor Actor, arg ListScheduledTransferRunsParams) ([]repo.ScheduledTransferRun, error) {
+	if _, err := bank.GetScheduledTransfer(ctx, actor, arg.ScheduledTransferID); err != nil {
+		return nil, err
+	}
+
+	return bank.store.ListScheduledTransferRuns(ctx, repo.ListScheduledTransferRunsParams{
+		ScheduledTransferID: arg.ScheduledTransferID,
+		AfterID:             arg.AfterID,
+		PageLimit:           arg.Limit,
+	})
+}
+
+// UpdateScheduledTransferParams changes a scheduled transfer, zero values are left unchanged
+type UpdateScheduledTransferParams struct {
+	ID     int64
+	Amount int64
+	// Currency of Amount, it must be the scheduled transfer's currency
+	Currency string
+	Schedule string
+	// StartAt moves the next occurrence, later ones follow the schedule from there
+	StartAt time.Time
+	// Paused stops executing occurrences until the transfer is resumed
+	Paused null.Bool
+}
+
+// UpdateScheduledTransfer changes the amount or the schedule of one of the actor's scheduled transfers, or pauses and resumes it.
+// Occurrences of a recurring transfer missed while it was paused are skipped rather than caught up.
+func (bank *Bank) UpdateScheduledTransfer(ctx context.Context, actor Actor, arg UpdateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+	scheduled, err := bank.GetScheduledTransfer(ctx, actor, arg.ID)
+	if err != nil {
+		return scheduled, err
+	}
+	if scheduled.IsFinished() {
+		return repo.ScheduledTransfer{}, fmt.Errorf("scheduled transfer [%d]: %w", arg.ID, ErrScheduledTransferFinished)
+	}
+
+	now := time.Now()
+	params := repo.UpdateScheduledTransferParams{ID: scheduled.ID}
+
+	if arg.Amount != 0 {
+		if arg.Amount < 0 {
+			return repo.ScheduledTransfer{}, fmt.Errorf("%w: must be positive", ErrInvalidAmount)
+		}
+		if arg.Currency != scheduled.Currency {
+			return repo.ScheduledTransfer{}, fmt.Errorf("scheduled transfer [%d] %w: %s vs %s", arg.ID, ErrCurrencyMismatch, scheduled.Currency, arg.Currency)
+		}
+		params.Amount = sql.NullInt64{Int64: arg.Amount, Valid: true}
+	}
+
+	if arg.Schedule != "" || !arg.StartAt.IsZero() {
+		spec := arg.Schedule
+		if spec == "" {
+			spec = scheduled.Schedule.String
+		}
+		nextRunAt, err := firstOccurrence(spec, arg.StartAt, now)
+		if err != nil {
+			return repo.ScheduledTransfer{}, err
+		}
+		params.Schedule = null.NewString(arg.Schedule, arg.Schedule != "")
+		params.NextRunAt = null.TimeFrom(nextRunAt)
+	}
+
+	if arg.Paused.Valid {
+		params.Status = null.StringFrom(repo.ScheduledTransferActive)
+		if arg.Paused.Bool {
+			params.Status = null.StringFrom(repo.ScheduledTransferPaused)
+		} else if scheduled.Status == repo.ScheduledTransferPaused && !params.NextRunAt.Valid &&
+			scheduled.Schedule.Valid && scheduled.NextRunAt.Time.Before(now) {
+			schedule, err := parseSchedule(scheduled.Schedule.String)
+			if err != nil {
+				return repo.ScheduledTransfer{}, err
+			}
+			params.NextRunAt = null.TimeFrom(schedule.Next(now))
+		}
+	}
+
+	updated, err := bank.store.UpdateScheduledTransfer(ctx, params)
+	if errors.Is(err, repo.ErrRecordNotFound) {
+		// completed or cancelled after it was read above
+		return updated, fmt.Errorf("scheduled transfer [%d]: %w", arg.ID, ErrScheduledTransferFinished)
+	}
+	return updated, err
+}
+
+// CancelScheduledTransfer stops one of the actor's scheduled transfers for good, its past runs are kept
+func (bank *Bank) CancelScheduledTransfer(ctx context.Context, actor Actor, id int64) (repo.ScheduledTransfer, error) {
+	if _, err := bank.GetScheduledTransfer(ctx, actor, id); err != nil {
+		return repo.ScheduledTransfer{}, err
+	}
+
+	cancelled, err := bank.store.CancelScheduledTransfer(ctx, id)
+	if errors.Is(err, repo.ErrRecordNotFound) {
+		return cancelled, fmt.Errorf("scheduled transfer [%d]: %w", id, ErrScheduledTransferFinished)
+	}
+	return cancelled, err
+}
+
+// RunScheduledTransfer executes the due occurrence of a scheduled transfer with the same checks as Transfer and records the outcome.
+// The transfer commits together with the record of its run, which only succeeds while the occurrence is still due,
+// so each occurrence is executed exactly once however many schedulers race for it.
+// Occurrences breaking a banking rule, such as insufficient funds, are recorded as failed and not retried,
+// other errors are returned and the occurrence is attempted again on the next poll.
+// Occurrences missed while no scheduler was running are skipped, only the due one is executed.
+func (bank *Bank) RunScheduledTransfer(ctx context.Context, scheduled repo.ScheduledTransfer) (repo.ScheduledTransferRun, error) {
+	record := repo.RecordScheduledTransferRunParams{
+		ScheduledTransferID: scheduled.ID,
+		ScheduledFor:        scheduled.NextRunAt.Time,
+		Status:              repo.ScheduledTransferCompleted,
+	}
+	if scheduled.Schedule.Valid {
+		schedule, err := parseSchedule(scheduled.Schedule.String)
+		if err != nil {
+			// the stored schedule will never parse, retrying the occurrence can't help
+			record.Status = repo.ScheduledTransferCancelled
+			return bank.recordFailedRun(ctx, record, err)
+		}
+		// like resuming a paused transfer, a scheduler outage doesn't fire every missed occurrence on restart
+		after := record.ScheduledFor
+		if now := time.Now(); after.Before(now) {
+			after = now
+		}
+		if nextRunAt := schedule.Next(after); !nextRunAt.IsZero() {
+			record.NextRunAt = null.TimeFrom(nextRunAt)
+			record.Status = repo.ScheduledTransferActive
+		}
+	}
+
+	owner, err := bank.store.GetUser(ctx, scheduled.Owner)
+	if err != nil {
+		return repo.ScheduledTransferRun{}, err
+	}
+	if !owner.IsEmailVerified {
+		return bank.recordFailedRun(ctx, record, ErrEmailNotVerified)
+	}
+
+	txArg, err := bank.prepareTransfer(ctx, scheduled.Owner, TransferParams{
+		FromAccountID: scheduled.FromAccountID,
+		ToAccountID:   scheduled.ToAccountID,
+		Amount:        scheduled.Amount,
+		Currency:      scheduled.Currency,
+	})
+	if err == nil {
+		var run repo.ScheduledTransferRun
+		_, err = bank.transfer(ctx, txArg, func(q repo.Querier, result repo.TransferTxResult) error {
+			succeeded := record
+			succeeded.TransferID = sql.NullInt64{Int64: result.Transfer.ID, Valid: true}
+
+			var err error
+			run, err = q.RecordScheduledTransferRun(ctx, succeeded)
+			if errors.Is(err, repo.ErrRecordNotFound) {
+				// rolls the transfer back
+				return ErrOccurrenceHandled
+			}
+			return err
+		})
+		if err == nil {
+			return run, nil
+		}
+	}
+
+	// errors without a code come from the database or the network rather than from the transfer itself
+	if errors.Is(err, ErrOccurrenceHandled) || apperr.CodeOf(err) == apperr.CodeInternal {
+		return repo.ScheduledTransferRun{}, err
+	}
+
+	return bank.recordFailedRun(ctx, record, err)
+}
+
+// recordFailedRun records that the due occurrence of a scheduled transfer failed with cause and moves the transfer past it
+func (bank *Bank) recordFailedRun(ctx context.Context, record repo.RecordScheduledTransferRunParams, cause error) (repo.ScheduledTransferRun, error) {
+	record.Error = null.StringFrom(cause.Error())
+	run, err := bank.store.RecordScheduledTransferRun(ctx, record)
+	if errors.Is(err, repo.ErrRecordNotFound) {
+		return run, ErrOccurrenceHandled
+	}
+	return run, err
+}
+
+// firstOccurrence works out when a transfer on spec starting at startAt runs first.
+// One-off transfers have no spec and run at startAt, recurring ones default to the next occurrence of spec after now.
+func firstOccurrence(spec string, startAt time.Time, now time.Time) (time.Time, error) {
+	if !startAt.IsZero() && !startAt.After(now) {
+		return time.Time{}, fmt.Errorf("%w: start must be in the future", ErrInvalidSchedule)
+	}
+
+	if spec == "" {
+		if startAt.IsZero() {
+			return time.Time{}, fmt.Errorf("%w: a one-off transfer needs a start", ErrInvalidSchedule)
+		}
+		return startAt, nil
+	}
+
+	schedule, err := parseSchedule(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if startAt.IsZero() {
+		return schedule.Next(now), nil
+	}
+	return startAt, nil
+}
+
+// parseSchedule reads a standard five field cron expression or a descriptor such as @monthly or @every 24h.
+// Expressions are evaluated in UTC unless they are prefixed with CRON_TZ=<zone>.
+func parseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSchedule, err)
+	}
+	if specSchedule, ok := schedule.(*cron.SpecSchedule); ok && specSchedule.Location == time.Local {
+		specSchedule.Location = time.UTC
+	}
+
+	next := schedule.Next(time.Now())
+	if next.IsZero() {
+		return nil, fmt.Errorf("%w: %q never runs", ErrInvalidSchedule, spec)
+	}
+	if schedule.Next(next).Sub(next) < minScheduleInterval {
+		return nil, fmt.Errorf("%w: %q runs more often than every %s", ErrInvalidSchedule, spec, minScheduleInterval)
+	}
+	return schedule, nil
+}
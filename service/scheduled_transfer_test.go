@@ -0,0 +1,599 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+)
+
+func TestCreateScheduledTransfer(t *testing.T) {
+	actor := randomActor()
+	other := randomActor()
+
+	fromAccount := randomAccount(actor.Username, testutils.USD)
+	toAccount := randomAccount(other.Username, testutils.USD)
+	fromAccount.ID, toAccount.ID = 1, 2
+
+	unverified := actor
+	unverified.EmailVerified = false
+
+	startAt := time.Now().Add(time.Hour)
+	arg := CreateScheduledTransferParams{
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+		Currency:      testutils.USD,
+	}
+
+	withSchedule := func(schedule string, startAt time.Time) CreateScheduledTransferParams {
+		scheduled := arg
+		scheduled.Schedule, scheduled.StartAt = schedule, startAt
+		return scheduled
+	}
+
+	stubAccounts := func(store *mockdb.MockStore) {
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+	}
+
+	testCases := []struct {
+		name       string
+		actor      Actor
+		arg        CreateScheduledTransferParams
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:  "Recurring",
+			actor: actor,
+			arg:   withSchedule("0 9 1 * *", time.Time{}),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.CreateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+						require.Equal(t, actor.Username, arg.Owner)
+						require.Equal(t, fromAccount.ID, arg.FromAccountID)
+						require.Equal(t, toAccount.ID, arg.ToAccountID)
+						require.Equal(t, int64(100), arg.Amount)
+						require.Equal(t, null.StringFrom("0 9 1 * *"), arg.Schedule)
+
+						// the first occurrence is the next 1st of the month at 9:00 UTC
+						next := arg.NextRunAt.Time.UTC()
+						require.True(t, next.After(time.Now()))
+						require.Equal(t, 1, next.Day())
+						require.Equal(t, 9, next.Hour())
+						return repo.ScheduledTransfer{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "OneOff",
+			actor: actor,
+			arg:   withSchedule("", startAt),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().
+					CreateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.CreateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+						require.False(t, arg.Schedule.Valid)
+						require.Equal(t, null.TimeFrom(startAt), arg.NextRunAt)
+						return repo.ScheduledTransfer{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "EmailNotVerified",
+			actor: unverified,
+			arg:   withSchedule("@monthly", time.Time{}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrEmailNotVerified)
+			},
+		},
+		{
+			name:  "AccountNotOwned",
+			actor: other,
+			arg:   withSchedule("@monthly", time.Time{}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotOwned)
+			},
+		},
+		{
+			name:  "InvalidSchedule",
+			actor: actor,
+			arg:   withSchedule("every monday", time.Time{}),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidSchedule)
+			},
+		},
+		{
+			name:  "TooFrequent",
+			actor: actor,
+			arg:   withSchedule("@every 10s", time.Time{}),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidSchedule)
+			},
+		},
+		{
+			name:  "OneOffWithoutStart",
+			actor: actor,
+			arg:   withSchedule("", time.Time{}),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidSchedule)
+			},
+		},
+		{
+			name:  "StartInThePast",
+			actor: actor,
+			arg:   withSchedule("@daily", time.Now().Add(-time.Hour)),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().CreateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidSchedule)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			_, err := bank.CreateScheduledTransfer(context.Background(), tc.actor, tc.arg)
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestUpdateScheduledTransfer(t *testing.T) {
+	actor := randomActor()
+
+	scheduled := randomScheduledTransfer(actor.Username)
+	paused := scheduled
+	paused.Status = repo.ScheduledTransferPaused
+	paused.Schedule = null.StringFrom("@daily")
+	paused.NextRunAt = null.TimeFrom(time.Now().Add(-48 * time.Hour))
+	completed := scheduled
+	completed.Status, completed.NextRunAt = repo.ScheduledTransferCompleted, null.Time{}
+
+	testCases := []struct {
+		name       string
+		actor      Actor
+		arg        UpdateScheduledTransferParams
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:  "Amount",
+			actor: actor,
+			arg:   UpdateScheduledTransferParams{ID: scheduled.ID, Amount: 50, Currency: scheduled.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				arg := repo.UpdateScheduledTransferParams{
+					Amount: sql.NullInt64{Int64: 50, Valid: true},
+					ID:     scheduled.ID,
+				}
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "AmountCurrencyMismatch",
+			actor: actor,
+			arg:   UpdateScheduledTransferParams{ID: scheduled.ID, Amount: 50, Currency: "XXX"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrCurrencyMismatch)
+			},
+		},
+		{
+			name:  "Pause",
+			actor: actor,
+			arg:   UpdateScheduledTransferParams{ID: scheduled.ID, Paused: null.BoolFrom(true)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				arg := repo.UpdateScheduledTransferParams{
+					Status: null.StringFrom(repo.ScheduledTransferPaused),
+					ID:     scheduled.ID,
+				}
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Eq(arg)).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "ResumeSkipsMissedOccurrences",
+			actor: actor,
+			arg:   UpdateScheduledTransferParams{ID: paused.ID, Paused: null.BoolFrom(false)},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(paused.ID)).Times(1).Return(paused, nil)
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.UpdateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+						require.Equal(t, null.StringFrom(repo.ScheduledTransferActive), arg.Status)
+						require.True(t, arg.NextRunAt.Time.After(time.Now()))
+						require.WithinDuration(t, time.Now(), arg.NextRunAt.Time, 24*time.Hour)
+						return repo.ScheduledTransfer{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "Reschedule",
+			actor: actor,
+			arg:   UpdateScheduledTransferParams{ID: scheduled.ID, Schedule: "@weekly"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().
+					UpdateScheduledTransfer(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.UpdateScheduledTransferParams) (repo.ScheduledTransfer, error) {
+						require.Equal(t, null.StringFrom("@weekly"), arg.Schedule)
+						require.Equal(t, time.Sunday, arg.NextRunAt.Time.UTC().Weekday())
+						return repo.ScheduledTransfer{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "Finished",
+			actor: actor,
+			arg:   UpdateScheduledTransferParams{ID: completed.ID, Amount: 50, Currency: completed.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(completed.ID)).Times(1).Return(completed, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrScheduledTransferFinished)
+			},
+		},
+		{
+			name:  "OtherOwner",
+			actor: randomActor(),
+			arg:   UpdateScheduledTransferParams{ID: scheduled.ID, Amount: 50, Currency: scheduled.Currency},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetScheduledTransfer(gomock.Any(), gomock.Eq(scheduled.ID)).Times(1).Return(scheduled, nil)
+				store.EXPECT().UpdateScheduledTransfer(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrScheduledTransferNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			_, err := bank.UpdateScheduledTransfer(context.Background(), tc.actor, tc.arg)
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestRunScheduledTransfer(t *testing.T) {
+	owner := testutils.RandomOwner()
+	fromAccount := randomAccount(owner, testutils.USD)
+	toAccount := randomAccount(testutils.RandomOwner(), testutils.USD)
+	fromAccount.ID, toAccount.ID = 1, 2
+
+	recurring := randomScheduledTransfer(owner)
+	recurring.FromAccountID, recurring.ToAccountID = fromAccount.ID, toAccount.ID
+	recurring.Schedule = null.StringFrom("@daily")
+	recurring.NextRunAt = null.TimeFrom(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC))
+
+	// due at the coming midnight, no occurrence was missed
+	onTime := recurring
+	onTime.NextRunAt = null.TimeFrom(time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour))
+
+	invalidSchedule := recurring
+	invalidSchedule.Schedule = null.StringFrom("@every 1s")
+
+	oneOff := recurring
+	oneOff.Schedule = null.String{}
+
+	stubOwner := func(store *mockdb.MockStore, verified bool) {
+		store.EXPECT().
+			GetUser(gomock.Any(), gomock.Eq(owner)).
+			Times(1).
+			Return(repo.User{Username: owner, IsEmailVerified: verified}, nil)
+	}
+
+	stubAccounts := func(store *mockdb.MockStore) {
+		stubOwner(store, true)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+		store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+	}
+
+	// transferAndRecord runs AfterTransfer the way TransferTx would
+	transferAndRecord := func(store *mockdb.MockStore, record func(arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error)) {
+		store.EXPECT().
+			TransferTx(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+				require.Equal(t, recurring.Amount, arg.Amount)
+				result := repo.TransferTxResult{Transfer: repo.Transfer{ID: 7}}
+				return result, arg.AfterTransfer(store, result)
+			})
+		store.EXPECT().
+			RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+			Times(1).
+			DoAndReturn(func(_ context.Context, arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+				return record(arg)
+			})
+	}
+
+	testCases := []struct {
+		name          string
+		scheduled     repo.ScheduledTransfer
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, run repo.ScheduledTransferRun, err error)
+	}{
+		{
+			name:      "Executed",
+			scheduled: recurring,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				transferAndRecord(store, func(arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+					require.Equal(t, recurring.ID, arg.ScheduledTransferID)
+					require.Equal(t, recurring.NextRunAt.Time, arg.ScheduledFor)
+					// the occurrences missed since 2024 are skipped
+					require.True(t, arg.NextRunAt.Time.After(time.Now()))
+					require.WithinDuration(t, time.Now(), arg.NextRunAt.Time, 24*time.Hour)
+					require.Zero(t, arg.NextRunAt.Time.Sub(arg.NextRunAt.Time.Truncate(24*time.Hour)))
+					require.Equal(t, repo.ScheduledTransferActive, arg.Status)
+					require.Equal(t, sql.NullInt64{Int64: 7, Valid: true}, arg.TransferID)
+					require.False(t, arg.Error.Valid)
+					return repo.ScheduledTransferRun{TransferID: arg.TransferID}, nil
+				})
+				store.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.NoError(t, err)
+				require.Equal(t, int64(7), run.TransferID.Int64)
+			},
+		},
+		{
+			name:      "NextOccurrenceFollowsSchedule",
+			scheduled: onTime,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				transferAndRecord(store, func(arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+					require.Equal(t, null.TimeFrom(onTime.NextRunAt.Time.Add(24*time.Hour)), arg.NextRunAt)
+					require.Equal(t, repo.ScheduledTransferActive, arg.Status)
+					return repo.ScheduledTransferRun{}, nil
+				})
+				store.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "OneOffCompleted",
+			scheduled: oneOff,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				transferAndRecord(store, func(arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+					require.False(t, arg.NextRunAt.Valid)
+					require.Equal(t, repo.ScheduledTransferCompleted, arg.Status)
+					return repo.ScheduledTransferRun{}, nil
+				})
+				store.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "AlreadyHandled",
+			scheduled: recurring,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				transferAndRecord(store, func(arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+					return repo.ScheduledTransferRun{}, repo.ErrRecordNotFound
+				})
+				store.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.ErrorIs(t, err, ErrOccurrenceHandled)
+			},
+		},
+		{
+			name:      "InsufficientFunds",
+			scheduled: recurring,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(repo.TransferTxResult{}, repo.ErrInsufficientFunds)
+				store.EXPECT().
+					RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+						require.False(t, arg.TransferID.Valid)
+						require.Contains(t, arg.Error.String, "insufficient funds")
+						// a failed occurrence doesn't stop the next ones
+						require.Equal(t, repo.ScheduledTransferActive, arg.Status)
+						require.True(t, arg.NextRunAt.Valid)
+						return repo.ScheduledTransferRun{Error: arg.Error}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.NoError(t, err)
+				require.True(t, run.Error.Valid)
+			},
+		},
+		{
+			name:      "AccountClosed",
+			scheduled: recurring,
+			buildStubs: func(store *mockdb.MockStore) {
+				closed := toAccount
+				closed.Status = repo.AccountStatusClosed
+				stubOwner(store, true)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(closed, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+						require.Contains(t, arg.Error.String, "account is not active")
+						return repo.ScheduledTransferRun{Error: arg.Error}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:      "EmailNotVerified",
+			scheduled: recurring,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubOwner(store, false)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+						require.Equal(t, ErrEmailNotVerified.Error(), arg.Error.String)
+						// later occurrences run once the owner verifies their email
+						require.Equal(t, repo.ScheduledTransferActive, arg.Status)
+						require.True(t, arg.NextRunAt.Valid)
+						return repo.ScheduledTransferRun{Error: arg.Error}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.NoError(t, err)
+				require.True(t, run.Error.Valid)
+			},
+		},
+		{
+			name:      "InvalidSchedule",
+			scheduled: invalidSchedule,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().
+					RecordScheduledTransferRun(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.RecordScheduledTransferRunParams) (repo.ScheduledTransferRun, error) {
+						require.Contains(t, arg.Error.String, ErrInvalidSchedule.Error())
+						require.Equal(t, repo.ScheduledTransferCancelled, arg.Status)
+						require.False(t, arg.NextRunAt.Valid)
+						return repo.ScheduledTransferRun{Error: arg.Error}, nil
+					})
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.NoError(t, err)
+				require.True(t, run.Error.Valid)
+			},
+		},
+		{
+			name:      "GetUserError",
+			scheduled: recurring,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(repo.User{}, sql.ErrConnDone)
+				store.EXPECT().RecordScheduledTransferRun(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+		{
+			name:      "InternalError",
+			scheduled: recurring,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubAccounts(store)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, sql.ErrConnDone)
+				store.EXPECT().RecordScheduledTransferRun(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, run repo.ScheduledTransferRun, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			run, err := bank.RunScheduledTransfer(context.Background(), tc.scheduled)
+			tc.checkResponse(t, run, err)
+		})
+	}
+}
+
+func randomScheduledTransfer(owner string) repo.ScheduledTransfer {
+	return repo.ScheduledTransfer{
+		ID:            testutils.RandomInt(1, 1000),
+		Owner:         owner,
+		FromAccountID: testutils.RandomInt(1, 1000),
+		ToAccountID:   testutils.RandomInt(1, 1000),
+		Amount:        testutils.RandomInt(1, 100),
+		Currency:      testutils.USD,
+		Schedule:      null.StringFrom("@monthly"),
+		NextRunAt:     null.TimeFrom(time.Now().Add(time.Hour)),
+		Status:        repo.ScheduledTransferActive,
+	}
+}
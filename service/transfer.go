@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/pkg/errors"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/exchange"
+	"github.com/simplebank/money"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
+	"github.com/simplebank/worker"
+)
+
+// TransferParams describes a transfer requested by an actor, Currency must be the source account's currency
+type TransferParams struct {
+	FromAccountID int64
+	ToAccountID   int64
+	Amount        int64
+	Currency      string
+	// optional details shown to both sides, they don't affect how the money moves
+	Description     string
+	ClientReference string
+	Metadata        map[string]string
+}
+
+// Transfer moves money out of one of the actor's accounts, converting it when the destination holds another currency.
+// The recipient is notified once the transfer commits.
+func (bank *Bank) Transfer(ctx context.Context, actor Actor, arg TransferParams) (repo.TransferTxResult, error) {
+	if !actor.EmailVerified {
+		return repo.TransferTxResult{}, ErrEmailNotVerified
+	}
+
+	txArg, err := bank.prepareTransfer(ctx, actor.Username, arg)
+	if err != nil {
+		return repo.TransferTxResult{}, err
+	}
+	return bank.transfer(ctx, txArg, nil)
+}
+
+// prepareTransfer checks a transfer out of one of owner's accounts and works out the amount the destination is credited with
+func (bank *Bank) prepareTransfer(ctx context.Context, owner string, arg TransferParams) (repo.TransferTxParams, error) {
+	if arg.Amount <= 0 {
+		return repo.TransferTxParams{}, fmt.Errorf("%w: must be positive", ErrInvalidAmount)
+	}
+
+	fromAccount, err := bank.fetchAccount(ctx, arg.FromAccountID)
+	if err != nil {
+		return repo.TransferTxParams{}, err
+	}
+	if fromAccount.Currency != arg.Currency {
+		return repo.TransferTxParams{}, fmt.Errorf("account [%d] %w: %s vs %s", fromAccount.ID, ErrCurrencyMismatch, fromAccount.Currency, arg.Currency)
+	}
+	if fromAccount.Owner != owner {
+		return repo.TransferTxParams{}, fmt.Errorf("from %w", ErrAccountNotOwned)
+	}
+
+	toAccount, err := bank.fetchAccount(ctx, arg.ToAccountID)
+	if err != nil {
+		return repo.TransferTxParams{}, err
+	}
+	// money only reaches clearing accounts through withdrawals
+	if toAccount.IsClearing() {
+		return repo.TransferTxParams{}, fmt.Errorf("account [%d]: %w", toAccount.ID, ErrAccountNotFound)
+	}
+
+	for _, account := range []repo.Account{fromAccount, toAccount} {
+		if !account.IsActive() {
+			return repo.TransferTxParams{}, fmt.Errorf("account [%d] is %s: %w", account.ID, account.Status, ErrAccountInactive)
+		}
+	}
+
+	txArg := repo.TransferTxParams{
+		FromAccountID:   fromAccount.ID,
+		ToAccountID:     toAccount.ID,
+		Amount:          arg.Amount,
+		Currency:        fromAccount.Currency,
+		Description:     arg.Description,
+		ClientReference: arg.ClientReference,
+	}
+	if len(arg.Metadata) > 0 {
+		txArg.Metadata, err = json.Marshal(arg.Metadata)
+		if err != nil {
+			return repo.TransferTxParams{}, err
+		}
+	}
+
+	if toAccount.Currency != fromAccount.Currency {
+		rate, err := bank.fxRates.Rate(ctx, fromAccount.Currency, toAccount.Currency)
+		if err != nil {
+			if errors.Is(err, exchange.ErrRateNotFound) {
+				return repo.TransferTxParams{}, fmt.Errorf("%w: %s to %s", ErrRateNotFound, fromAccount.Currency, toAccount.Currency)
+			}
+			return repo.TransferTxParams{}, err
+		}
+
+		txArg.ToAmount, err = rate.Convert(arg.Amount)
+		if err != nil {
+			return repo.TransferTxParams{}, fmt.Errorf("%w: %s", ErrInvalidAmount, err)
+		}
+		if txArg.ToAmount <= 0 {
+			return repo.TransferTxParams{}, fmt.Errorf("%w: %s is too small to convert to %s", ErrInvalidAmount, money.New(arg.Amount, fromAccount.Currency), toAccount.Currency)
+		}
+		txArg.ToCurrency = toAccount.Currency
+		txArg.ExchangeRate = rate.String()
+	}
+
+	return txArg, nil
+}
+
+// transfer executes a prepared transfer and enqueues the recipient's notification with it.
+// afterTransfer, when not nil, runs first inside the same transaction.
+func (bank *Bank) transfer(ctx context.Context, txArg repo.TransferTxParams, afterTransfer func(q repo.Querier, result repo.TransferTxResult) error) (repo.TransferTxResult, error) {
+	txArg.AfterTransfer = func(q repo.Querier, result repo.TransferTxResult) error {
+		if afterTransfer != nil {
+			if err := afterTransfer(q, result); err != nil {
+				return err
+			}
+		}
+		return worker.EnqueueSendTransferNotification(ctx, q, worker.PayloadSendTransferNotification{
+			TransferID: result.Transfer.ID,
+		})
+	}
+
+	result, err := bank.store.TransferTx(ctx, txArg)
+	switch {
+	case errors.Is(err, repo.ErrInsufficientFunds):
+		return result, fmt.Errorf("account [%d]: %w", txArg.FromAccountID, ErrInsufficientFunds)
+	case errors.Is(err, repo.ErrAccountInactive):
+		// one of the accounts was frozen or closed after it was read
+		return result, ErrAccountInactive
+	}
+	return result, err
+}
+
+// GetTransfer returns a transfer the actor sent or received
+func (bank *Bank) GetTransfer(ctx context.Context, actor Actor, transferID int64) (repo.Transfer, error) {
+	transfer, err := bank.store.GetTransfer(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return transfer, fmt.Errorf("transfer [%d]: %w", transferID, ErrTransferNotFound)
+		}
+		return transfer, err
+	}
+
+	for _, accountID := range []int64{transfer.FromAccountID, transfer.ToAccountID} {
+		account, err := bank.store.GetAccount(ctx, accountID)
+		if err != nil {
+			return repo.Transfer{}, err
+		}
+
+		if account.Owner == actor.Username {
+			return transfer, nil
+		}
+	}
+
+	return repo.Transfer{}, ErrTransferNotOwned
+}
+
+// ReverseTransfer gives a transfer back by moving the amounts it credited and debited in the opposite direction,
+// the reversal is recorded as a new transfer linked to the original. Only the recipient or an admin can reverse a transfer,
+// and only once. The recipient's account must still hold the money, it isn't allowed to go further than its overdraft limit.
+func (bank *Bank) ReverseTransfer(ctx context.Context, actor Actor, transferID int64) (repo.TransferTxResult, error) {
+	original, err := bank.store.GetTransfer(ctx, transferID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return repo.TransferTxResult{}, fmt.Errorf("transfer [%d]: %w", transferID, ErrTransferNotFound)
+		}
+		return repo.TransferTxResult{}, err
+	}
+
+	if actor.Role != token.RoleAdmin {
+		recipient, err := bank.fetchAccount(ctx, original.ToAccountID)
+		if err != nil {
+			return repo.TransferTxResult{}, err
+		}
+		if recipient.Owner != actor.Username {
+			sender, err := bank.fetchAccount(ctx, original.FromAccountID)
+			if err != nil {
+				return repo.TransferTxResult{}, err
+			}
+			// the sender and tellers get to know why, anybody else shouldn't learn about the transfer
+			if sender.Owner != actor.Username && !actor.IsStaff() {
+				return repo.TransferTxResult{}, ErrTransferNotOwned
+			}
+			return repo.TransferTxResult{}, ErrReverseNotAllowed
+		}
+	}
+
+	if original.ReversalOf.Valid {
+		return repo.TransferTxResult{}, fmt.Errorf("transfer [%d]: %w", original.ID, ErrReversalNotReversible)
+	}
+
+	_, err = bank.store.GetTransferReversal(ctx, sql.NullInt64{Int64: original.ID, Valid: true})
+	if err == nil {
+		return repo.TransferTxResult{}, fmt.Errorf("transfer [%d]: %w", original.ID, ErrTransferAlreadyReversed)
+	}
+	if !errors.Is(err, repo.ErrRecordNotFound) {
+		return repo.TransferTxResult{}, err
+	}
+
+	txArg := repo.TransferTxParams{
+		FromAccountID: original.ToAccountID,
+		ToAccountID:   original.FromAccountID,
+		Amount:        original.ToAmount,
+		Currency:      original.ToCurrency,
+		ReversalOf:    original.ID,
+	}
+
+	// the original amounts are given back as they were, the rate is the one they imply rather than today's
+	if original.ToCurrency != original.Currency {
+		rate, err := exchange.NewRate(original.ToCurrency, original.Currency, big.NewRat(original.Amount, original.ToAmount))
+		if err != nil {
+			return repo.TransferTxResult{}, err
+		}
+		txArg.ToAmount = original.Amount
+		txArg.ToCurrency = original.Currency
+		txArg.ExchangeRate = rate.String()
+	}
+
+	result, err := bank.transfer(ctx, txArg, nil)
+	if repo.ErrorCode(err) == repo.UniqueViolation {
+		// reversed concurrently since it was checked above
+		return result, fmt.Errorf("transfer [%d]: %w", original.ID, ErrTransferAlreadyReversed)
+	}
+	return result, err
+}
+
+// ListTransfersParams selects a page of the actor's transfers, newest first. Zero values leave a filter out.
+type ListTransfersParams struct {
+	// "in", "out" or empty for both directions
+	Direction             string
+	CounterpartyAccountID int64
+	// MinAmount and MaxAmount are minor units of Currency, compared with what left outgoing
+	// transfers and what arrived on incoming ones
+	Currency  string
+	MinAmount int64
+	MaxAmount int64
+	StartTime             time.Time
+	EndTime               time.Time
+	// case-insensitive text the description must contain
+	Search          string
+	ClientReference string
+	// MetadataKey, when set, only keeps transfers whose metadata has MetadataValue under that key
+	MetadataKey   string
+	MetadataValue string
+	BeforeID      int64
+	Limit         int32
+}
+
+// ListTransfers lists transfers sent or received by any of the actor's accounts
+func (bank *Bank) ListTransfers(ctx context.Context, actor Actor, arg ListTransfersParams) ([]repo.Transfer, error) {
+	params := repo.ListUserTransfersParams{
+		Owner:     actor.Username,
+		Direction: arg.Direction,
+		BeforeID:  arg.BeforeID,
+		PageLimit: arg.Limit,
+	}
+	if arg.CounterpartyAccountID != 0 {
+		params.CounterpartyAccountID = sql.NullInt64{Int64: arg.CounterpartyAccountID, Valid: true}
+	}
+	if arg.Currency != "" {
+		params.AmountCurrency = null.StringFrom(arg.Currency)
+	}
+	if arg.MinAmount != 0 {
+		params.MinAmount = sql.NullInt64{Int64: arg.MinAmount, Valid: true}
+	}
+	if arg.MaxAmount != 0 {
+		params.MaxAmount = sql.NullInt64{Int64: arg.MaxAmount, Valid: true}
+	}
+	if !arg.StartTime.IsZero() {
+		params.StartTime = null.TimeFrom(arg.StartTime)
+	}
+	if !arg.EndTime.IsZero() {
+		params.EndTime = null.TimeFrom(arg.EndTime)
+	}
+	if arg.Search != "" {
+		params.Search = null.StringFrom(arg.Search)
+	}
+	if arg.ClientReference != "" {
+		params.ClientReference = null.StringFrom(arg.ClientReference)
+	}
+	if arg.MetadataKey != "" {
+		params.MetadataKey = null.StringFrom(arg.MetadataKey)
+		params.MetadataValue = arg.MetadataValue
+	}
+
+	return bank.store.ListUserTransfers(ctx, params)
+}
@@ -0,0 +1,564 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+	"github.com/simplebank/token"
+	"github.com/simplebank/worker"
+)
+
+func TestTransfer(t *testing.T) {
+	amount := int64(100)
+
+	actor := randomActor()
+	other := randomActor()
+
+	fromAccount := randomAccount(actor.Username, testutils.USD)
+	toAccount := randomAccount(other.Username, testutils.USD)
+	eurAccount := randomAccount(other.Username, testutils.EUR)
+	cadAccount := randomAccount(other.Username, testutils.CAD)
+	fromAccount.ID, toAccount.ID, eurAccount.ID, cadAccount.ID = 1, 2, 3, 4
+
+	frozenAccount := randomAccount(other.Username, testutils.USD)
+	frozenAccount.ID, frozenAccount.Status = 5, repo.AccountStatusFrozen
+
+	unverified := actor
+	unverified.EmailVerified = false
+
+	testCases := []struct {
+		name       string
+		actor      Actor
+		arg        TransferParams
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:  "OK",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						require.Equal(t, fromAccount.ID, arg.FromAccountID)
+						require.Equal(t, toAccount.ID, arg.ToAccountID)
+						require.Equal(t, amount, arg.Amount)
+						require.Zero(t, arg.ToAmount)
+						require.Empty(t, arg.ExchangeRate)
+						require.Equal(t, testutils.USD, arg.Currency)
+						require.Empty(t, arg.ToCurrency)
+						require.Empty(t, arg.Metadata)
+
+						result := repo.TransferTxResult{Transfer: repo.Transfer{ID: 7}}
+						return result, arg.AfterTransfer(store, result)
+					})
+				store.EXPECT().
+					EnqueueJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.EnqueueJobParams) (repo.Job, error) {
+						require.Equal(t, worker.TaskSendTransferNotification, arg.Kind)
+						require.JSONEq(t, `{"transfer_id":7}`, string(arg.Payload))
+						return repo.Job{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "Converted",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: eurAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(eurAccount.ID)).Times(1).Return(eurAccount, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						require.Equal(t, int64(92), arg.ToAmount)
+						require.Equal(t, "0.92000000", arg.ExchangeRate)
+						require.Equal(t, testutils.USD, arg.Currency)
+						require.Equal(t, testutils.EUR, arg.ToCurrency)
+						return repo.TransferTxResult{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "WithDetails",
+			actor: actor,
+			arg: TransferParams{
+				FromAccountID:   fromAccount.ID,
+				ToAccountID:     toAccount.ID,
+				Amount:          amount,
+				Currency:        testutils.USD,
+				Description:     "Rent for March",
+				ClientReference: "INV-42",
+				Metadata:        map[string]string{"order_id": "1234"},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						require.Equal(t, "Rent for March", arg.Description)
+						require.Equal(t, "INV-42", arg.ClientReference)
+						require.JSONEq(t, `{"order_id":"1234"}`, string(arg.Metadata))
+						return repo.TransferTxResult{}, nil
+					})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "EmailNotVerified",
+			actor: unverified,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrEmailNotVerified)
+			},
+		},
+		{
+			name:  "InvalidAmount",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 0, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInvalidAmount)
+			},
+		},
+		{
+			name:  "FromAccountNotFound",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(repo.Account{}, repo.ErrRecordNotFound)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotFound)
+			},
+		},
+		{
+			name:  "CurrencyMismatch",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount, Currency: testutils.EUR},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrCurrencyMismatch)
+			},
+		},
+		{
+			name:  "NotOwner",
+			actor: other,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountNotOwned)
+			},
+		},
+		{
+			name:  "RateNotFound",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: cadAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(cadAccount.ID)).Times(1).Return(cadAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrRateNotFound)
+			},
+		},
+		{
+			name:  "InsufficientFunds",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrInsufficientFunds)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInsufficientFunds)
+			},
+		},
+		{
+			name:  "InactiveAccount",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: frozenAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(frozenAccount.ID)).Times(1).Return(frozenAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountInactive)
+			},
+		},
+		{
+			name:  "InactiveDuringTransfer",
+			actor: actor,
+			arg:   TransferParams{FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: amount, Currency: testutils.USD},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrAccountInactive)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrAccountInactive)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			_, err := bank.Transfer(context.Background(), tc.actor, tc.arg)
+			tc.checkError(t, err)
+		})
+	}
+}
+
+func TestGetTransfer(t *testing.T) {
+	sender := randomActor()
+	recipient := randomActor()
+
+	fromAccount := randomAccount(sender.Username, testutils.USD)
+	toAccount := randomAccount(recipient.Username, testutils.USD)
+	fromAccount.ID, toAccount.ID = 1, 2
+
+	transfer := repo.Transfer{ID: 9, FromAccountID: fromAccount.ID, ToAccountID: toAccount.ID, Amount: 10}
+
+	testCases := []struct {
+		name       string
+		actor      Actor
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:  "Sender",
+			actor: sender,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "Recipient",
+			actor: recipient,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "Stranger",
+			actor: randomActor(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(_ context.Context, id int64) (repo.Account, error) {
+					if id == fromAccount.ID {
+						return fromAccount, nil
+					}
+					return toAccount, nil
+				})
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTransferNotOwned)
+			},
+		},
+		{
+			name:  "NotFound",
+			actor: sender,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(repo.Transfer{}, repo.ErrRecordNotFound)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTransferNotFound)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			got, err := bank.GetTransfer(context.Background(), tc.actor, transfer.ID)
+			tc.checkError(t, err)
+			if err == nil {
+				require.Equal(t, transfer, got)
+			}
+		})
+	}
+}
+
+func TestReverseTransfer(t *testing.T) {
+	sender := randomActor()
+	recipient := randomActor()
+	admin := randomActor()
+	admin.Role = token.RoleAdmin
+
+	fromAccount := randomAccount(sender.Username, testutils.USD)
+	toAccount := randomAccount(recipient.Username, testutils.USD)
+	fromAccount.ID, toAccount.ID = 1, 2
+
+	transfer := repo.Transfer{
+		ID:            9,
+		FromAccountID: fromAccount.ID,
+		ToAccountID:   toAccount.ID,
+		Amount:        100,
+		ToAmount:      100,
+		ExchangeRate:  "1",
+		Currency:      testutils.USD,
+		ToCurrency:    testutils.USD,
+	}
+	converted := transfer
+	converted.ToAmount, converted.ToCurrency, converted.ExchangeRate = 92, testutils.EUR, "0.92000000"
+	reversal := repo.Transfer{ID: 10, ReversalOf: sql.NullInt64{Int64: transfer.ID, Valid: true}}
+
+	stubTransfer := func(store *mockdb.MockStore, original repo.Transfer) {
+		store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(original.ID)).Times(1).Return(original, nil)
+	}
+	stubNotReversed := func(store *mockdb.MockStore) {
+		store.EXPECT().
+			GetTransferReversal(gomock.Any(), gomock.Eq(sql.NullInt64{Int64: transfer.ID, Valid: true})).
+			Times(1).
+			Return(repo.Transfer{}, repo.ErrRecordNotFound)
+	}
+
+	testCases := []struct {
+		name       string
+		actor      Actor
+		buildStubs func(store *mockdb.MockStore)
+		checkError func(t *testing.T, err error)
+	}{
+		{
+			name:  "Recipient",
+			actor: recipient,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubTransfer(store, transfer)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				stubNotReversed(store)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						require.Equal(t, toAccount.ID, arg.FromAccountID)
+						require.Equal(t, fromAccount.ID, arg.ToAccountID)
+						require.Equal(t, transfer.Amount, arg.Amount)
+						require.Equal(t, testutils.USD, arg.Currency)
+						require.Zero(t, arg.ToAmount)
+						require.Equal(t, transfer.ID, arg.ReversalOf)
+
+						result := repo.TransferTxResult{Transfer: reversal}
+						return result, arg.AfterTransfer(store, result)
+					})
+				store.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "AdminConverted",
+			actor: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubTransfer(store, converted)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Any()).Times(0)
+				stubNotReversed(store)
+				store.EXPECT().
+					TransferTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.TransferTxParams) (repo.TransferTxResult, error) {
+						// the original amounts are given back whatever today's rate is
+						require.Equal(t, int64(92), arg.Amount)
+						require.Equal(t, testutils.EUR, arg.Currency)
+						require.Equal(t, int64(100), arg.ToAmount)
+						require.Equal(t, testutils.USD, arg.ToCurrency)
+						require.Equal(t, "1.08695652", arg.ExchangeRate)
+
+						result := repo.TransferTxResult{Transfer: reversal}
+						return result, arg.AfterTransfer(store, result)
+					})
+				store.EXPECT().EnqueueJob(gomock.Any(), gomock.Any()).Times(1)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:  "Sender",
+			actor: sender,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubTransfer(store, transfer)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrReverseNotAllowed)
+			},
+		},
+		{
+			name:  "Stranger",
+			actor: randomActor(),
+			buildStubs: func(store *mockdb.MockStore) {
+				stubTransfer(store, transfer)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(fromAccount.ID)).Times(1).Return(fromAccount, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTransferNotOwned)
+			},
+		},
+		{
+			name:  "AlreadyReversed",
+			actor: recipient,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubTransfer(store, transfer)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				store.EXPECT().GetTransferReversal(gomock.Any(), gomock.Any()).Times(1).Return(reversal, nil)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+			},
+		},
+		{
+			name:  "ReversedConcurrently",
+			actor: recipient,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubTransfer(store, transfer)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				stubNotReversed(store)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrUniqueViolation)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrTransferAlreadyReversed)
+			},
+		},
+		{
+			name:  "Reversal",
+			actor: admin,
+			buildStubs: func(store *mockdb.MockStore) {
+				reversal := reversal
+				reversal.ID = transfer.ID
+				stubTransfer(store, reversal)
+				store.EXPECT().GetTransferReversal(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrReversalNotReversible)
+			},
+		},
+		{
+			name:  "InsufficientFunds",
+			actor: recipient,
+			buildStubs: func(store *mockdb.MockStore) {
+				stubTransfer(store, transfer)
+				store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+				stubNotReversed(store)
+				store.EXPECT().TransferTx(gomock.Any(), gomock.Any()).Times(1).Return(repo.TransferTxResult{}, repo.ErrInsufficientFunds)
+			},
+			checkError: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, ErrInsufficientFunds)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			bank, store := newTestBank(t)
+			tc.buildStubs(store)
+
+			result, err := bank.ReverseTransfer(context.Background(), tc.actor, transfer.ID)
+			tc.checkError(t, err)
+			if err == nil {
+				require.Equal(t, reversal, result.Transfer)
+			}
+		})
+	}
+}
+
+func TestListTransfers(t *testing.T) {
+	bank, store := newTestBank(t)
+	actor := randomActor()
+	startTime := time.Now().Add(-time.Hour)
+
+	arg := repo.ListUserTransfersParams{
+		Owner:          actor.Username,
+		Direction:      "in",
+		AmountCurrency: null.StringFrom(testutils.EUR),
+		Search:         null.StringFrom("rent"),
+		MetadataKey:    null.StringFrom("order_id"),
+		MetadataValue:  "1234",
+		BeforeID:       100,
+		PageLimit:      5,
+	}
+	arg.MinAmount.Int64, arg.MinAmount.Valid = 10, true
+	arg.StartTime = null.TimeFrom(startTime)
+
+	transfers := []repo.Transfer{{ID: 1}}
+	store.EXPECT().ListUserTransfers(gomock.Any(), gomock.Eq(arg)).Times(1).Return(transfers, nil)
+
+	got, err := bank.ListTransfers(context.Background(), actor, ListTransfersParams{
+		Direction:     "in",
+		Currency:      testutils.EUR,
+		MinAmount:     10,
+		StartTime:     startTime,
+		Search:        "rent",
+		MetadataKey:   "order_id",
+		MetadataValue: "1234",
+		BeforeID:      100,
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, transfers, got)
+}
@@ -0,0 +1,17 @@
+package token
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Denylist is an interface for tracking tokens that were revoked before they expired
+type Denylist interface {
+	// Revoke denies the token with the given payload id until it expires
+	Revoke(ctx context.Context, tokenID uuid.UUID, expiresAt time.Time) error
+
+	// IsRevoked checks if the token with the given payload id has been revoked
+	IsRevoked(ctx context.Context, tokenID uuid.UUID) (bool, error)
+}
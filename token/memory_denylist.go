@@ -0,0 +1,48 @@
+package token
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MemoryDenylist keeps revoked tokens in process memory, it is only suitable for a single server instance
+type MemoryDenylist struct {
+	mu      sync.RWMutex
+	revoked map[uuid.UUID]time.Time
+}
+
+// NewMemoryDenylist creates a new MemoryDenylist
+func NewMemoryDenylist() Denylist {
+	return &MemoryDenylist{
+		revoked: make(map[uuid.UUID]time.Time),
+	}
+}
+
+// Revoke denies the token with the given payload id until it expires
+func (denylist *MemoryDenylist) Revoke(_ context.Context, tokenID uuid.UUID, expiresAt time.Time) error {
+	denylist.mu.Lock()
+	defer denylist.mu.Unlock()
+
+	// drop entries whose tokens have expired on their own so the map doesn't grow forever
+	now := time.Now()
+	for id, expiry := range denylist.revoked {
+		if now.After(expiry) {
+			delete(denylist.revoked, id)
+		}
+	}
+
+	denylist.revoked[tokenID] = expiresAt
+	return nil
+}
+
+// IsRevoked checks if the token with the given payload id has been revoked
+func (denylist *MemoryDenylist) IsRevoked(_ context.Context, tokenID uuid.UUID) (bool, error) {
+	denylist.mu.RLock()
+	defer denylist.mu.RUnlock()
+
+	expiresAt, ok := denylist.revoked[tokenID]
+	return ok && time.Now().Before(expiresAt), nil
+}
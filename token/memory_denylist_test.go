@@ -0,0 +1,49 @@
+package token
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryDenylist(t *testing.T) {
+	ctx := context.Background()
+	denylist := NewMemoryDenylist()
+
+	tokenID := uuid.New()
+	revoked, err := denylist.IsRevoked(ctx, tokenID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	err = denylist.Revoke(ctx, tokenID, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+
+	revoked, err = denylist.IsRevoked(ctx, tokenID)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = denylist.IsRevoked(ctx, uuid.New())
+	require.NoError(t, err)
+	require.False(t, revoked)
+}
+
+func TestMemoryDenylistExpiredToken(t *testing.T) {
+	ctx := context.Background()
+	denylist := NewMemoryDenylist()
+
+	expiredID := uuid.New()
+	err := denylist.Revoke(ctx, expiredID, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+
+	revoked, err := denylist.IsRevoked(ctx, expiredID)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// revoking another token purges entries that have expired
+	err = denylist.Revoke(ctx, uuid.New(), time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.NotContains(t, denylist.(*MemoryDenylist).revoked, expiredID)
+}
@@ -0,0 +1,8 @@
+package token
+
+// Roles a user can be granted, embedded in every token issued for them
+const (
+	RoleCustomer = "customer"
+	RoleTeller   = "teller"
+	RoleAdmin    = "admin"
+)
@@ -0,0 +1,25 @@
+package token
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+const secretTokenBytes = 32
+
+// NewSecretToken generates a random single-use token to send to a user, only its hash should be stored
+func NewSecretToken() (string, error) {
+	buf := make([]byte, secretTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashSecretToken returns the hex encoded sha256 of a secret token, which is what gets stored and looked up
+func HashSecretToken(secretToken string) string {
+	sum := sha256.Sum256([]byte(secretToken))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,22 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretToken(t *testing.T) {
+	secretToken1, err := NewSecretToken()
+	require.NoError(t, err)
+	require.Len(t, secretToken1, 43)
+
+	secretToken2, err := NewSecretToken()
+	require.NoError(t, err)
+	require.NotEqual(t, secretToken1, secretToken2)
+
+	hash := HashSecretToken(secretToken1)
+	require.Len(t, hash, 64)
+	require.Equal(t, hash, HashSecretToken(secretToken1))
+	require.NotEqual(t, hash, HashSecretToken(secretToken2))
+}
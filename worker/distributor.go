@@ -0,0 +1,32 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/simplebank/repo"
+)
+
+const defaultMaxAttempts = 5
+
+// enqueue stores a job for the processor to pick up.
+// Passing the Querier of a db transaction makes the job appear only if that transaction commits.
+func enqueue(ctx context.Context, q repo.Querier, kind string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s payload: %w", kind, err)
+	}
+
+	_, err = q.EnqueueJob(ctx, repo.EnqueueJobParams{
+		Kind:        kind,
+		Payload:     data,
+		MaxAttempts: defaultMaxAttempts,
+		RunAt:       time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s job: %w", kind, err)
+	}
+	return nil
+}
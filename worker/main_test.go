@@ -0,0 +1,44 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/mail"
+	mockdb "github.com/simplebank/repo/mock"
+)
+
+// recordingMailer keeps sent messages in memory
+type recordingMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (mailer *recordingMailer) Send(_ context.Context, msg mail.Message) error {
+	if mailer.err != nil {
+		return mailer.err
+	}
+	mailer.sent = append(mailer.sent, msg)
+	return nil
+}
+
+func newTestProcessor(t *testing.T) (*Processor, *mockdb.MockStore, *recordingMailer) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+
+	store := mockdb.NewMockStore(ctrl)
+	mailer := &recordingMailer{}
+
+	appConfig := &config.Config{
+		VerifyEmailTokenDuration:   time.Hour,
+		PasswordResetTokenDuration: time.Minute,
+		WorkerConcurrency:          1,
+		WorkerPollInterval:         time.Millisecond,
+	}
+
+	return NewProcessor(appConfig, store, mailer), store, mailer
+}
@@ -0,0 +1,186 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	null "gopkg.in/guregu/null.v4"
+
+	"github.com/simplebank/config"
+	"github.com/simplebank/mail"
+	"github.com/simplebank/repo"
+)
+
+const (
+	// jobTimeout bounds how long a job may run
+	jobTimeout = 5 * time.Minute
+	// lockTimeout is when another worker can reclaim a running job, it outlasts jobTimeout
+	// so the worker still holding the job has time to record its outcome
+	lockTimeout    = jobTimeout + time.Minute
+	retryBaseDelay = 10 * time.Second
+	retryMaxDelay  = time.Hour
+)
+
+// errSkipRetry marks failures that can't succeed on a later attempt, the job is dead-lettered right away
+var errSkipRetry = errors.New("job can't be retried")
+
+// errJobReclaimed is returned when the outcome of a job can't be recorded because another worker claimed it since
+var errJobReclaimed = errors.New("job was reclaimed by another worker")
+
+type handlerFunc func(ctx context.Context, payload json.RawMessage) error
+
+// Processor claims jobs from the database queue and runs them
+type Processor struct {
+	store     repo.Store
+	mailer    mail.Mailer
+	appConfig *config.Config
+	handlers  map[string]handlerFunc
+}
+
+// NewProcessor creates a new Processor
+func NewProcessor(appConfig *config.Config, store repo.Store, mailer mail.Mailer) *Processor {
+	processor := &Processor{
+		store:     store,
+		mailer:    mailer,
+		appConfig: appConfig,
+	}
+	processor.handlers = map[string]handlerFunc{
+		TaskSendVerifyEmail:          processor.processSendVerifyEmail,
+		TaskSendPasswordReset:        processor.processSendPasswordReset,
+		TaskSendTransferNotification: processor.processSendTransferNotification,
+	}
+	return processor
+}
+
+// Start runs WorkerConcurrency polling loops until ctx is cancelled
+func (processor *Processor) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for i := 0; i < processor.appConfig.WorkerConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			processor.poll(ctx)
+		}()
+	}
+
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (processor *Processor) poll(ctx context.Context) {
+	for {
+		processed, err := processor.ProcessNext(ctx)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to process job")
+		}
+
+		// keep draining the queue while there is work, otherwise wait for new jobs
+		if processed && err == nil {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(processor.appConfig.WorkerPollInterval):
+		}
+	}
+}
+
+// ProcessNext claims a single due job and runs it.
+// It reports whether a job was claimed, failures of the job itself are recorded on the job rather than returned.
+func (processor *Processor) ProcessNext(ctx context.Context) (bool, error) {
+	staleBefore := time.Now().Add(-lockTimeout)
+
+	// jobs that already used their last attempt aren't reclaimed, they would otherwise stay running forever
+	killed, err := processor.store.KillStaleJobs(ctx, staleBefore)
+	if err != nil {
+		return false, fmt.Errorf("failed to kill stale jobs: %w", err)
+	}
+	if killed > 0 {
+		log.Error().Int64("jobs", killed).Msg("stale jobs out of attempts are dead")
+	}
+
+	job, err := processor.store.ClaimJob(ctx, staleBefore)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+
+	err = processor.run(jobCtx, job)
+	if err == nil {
+		return true, checkClaimed(processor.store.CompleteJob(ctx, repo.CompleteJobParams{
+			ID:       job.ID,
+			Attempts: job.Attempts,
+		}))
+	}
+
+	logger := log.With().Int64("job_id", job.ID).Str("kind", job.Kind).Int32("attempts", job.Attempts).Logger()
+	if errors.Is(err, errSkipRetry) || job.Attempts >= job.MaxAttempts {
+		logger.Error().Err(err).Msg("job is dead")
+		return true, checkClaimed(processor.store.KillJob(ctx, repo.KillJobParams{
+			LastError: null.StringFrom(err.Error()),
+			ID:        job.ID,
+			Attempts:  job.Attempts,
+		}))
+	}
+
+	logger.Warn().Err(err).Msg("job failed, retrying")
+	return true, checkClaimed(processor.store.RetryJob(ctx, repo.RetryJobParams{
+		RunAt:     time.Now().Add(backoff(job.Attempts)),
+		LastError: null.StringFrom(err.Error()),
+		ID:        job.ID,
+		Attempts:  job.Attempts,
+	}))
+}
+
+// checkClaimed turns an update of a job that no longer matched our claim into errJobReclaimed
+func checkClaimed(rows int64, err error) error {
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errJobReclaimed
+	}
+	return nil
+}
+
+func (processor *Processor) run(ctx context.Context, job repo.Job) error {
+	handler, ok := processor.handlers[job.Kind]
+	if !ok {
+		return fmt.Errorf("unknown job kind %q: %w", job.Kind, errSkipRetry)
+	}
+	return handler(ctx, job.Payload)
+}
+
+// backoff doubles the delay after every failed attempt, up to retryMaxDelay
+func backoff(attempts int32) time.Duration {
+	delay := retryBaseDelay
+	for i := int32(1); i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+func decodePayload(payload json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %v: %w", err, errSkipRetry)
+	}
+	return nil
+}
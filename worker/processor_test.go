@@ -0,0 +1,229 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	mockdb "github.com/simplebank/repo/mock"
+)
+
+func TestProcessNext(t *testing.T) {
+	user := repo.User{
+		Username: testutils.RandomOwner(),
+		FullName: testutils.RandomOwner(),
+		Email:    testutils.RandomEmail(),
+	}
+
+	payload, err := json.Marshal(PayloadSendVerifyEmail{Username: user.Username, Email: user.Email})
+	require.NoError(t, err)
+
+	job := repo.Job{
+		ID:          1,
+		Kind:        TaskSendVerifyEmail,
+		Payload:     payload,
+		Status:      "running",
+		Attempts:    1,
+		MaxAttempts: 3,
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(t *testing.T, processed bool, err error)
+	}{
+		{
+			name: "NoJob",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(1).Return(repo.Job{}, repo.ErrRecordNotFound)
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.NoError(t, err)
+				require.False(t, processed)
+			},
+		},
+		{
+			name: "Completed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(1).Return(job, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1)
+				arg := repo.CompleteJobParams{ID: job.ID, Attempts: job.Attempts}
+				store.EXPECT().CompleteJob(gomock.Any(), gomock.Eq(arg)).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.NoError(t, err)
+				require.True(t, processed)
+			},
+		},
+		{
+			name: "Reclaimed",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(1).Return(job, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+				store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(1)
+				store.EXPECT().CompleteJob(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.ErrorIs(t, err, errJobReclaimed)
+				require.True(t, processed)
+			},
+		},
+		{
+			name: "Retried",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(1).Return(job, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(repo.User{}, sql.ErrConnDone)
+				store.EXPECT().
+					RetryJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg repo.RetryJobParams) (int64, error) {
+						require.Equal(t, job.ID, arg.ID)
+						require.Equal(t, job.Attempts, arg.Attempts)
+						require.Equal(t, sql.ErrConnDone.Error(), arg.LastError.String)
+						require.WithinDuration(t, time.Now().Add(retryBaseDelay), arg.RunAt, time.Second)
+						return 1, nil
+					})
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.NoError(t, err)
+				require.True(t, processed)
+			},
+		},
+		{
+			name: "OutOfAttempts",
+			buildStubs: func(store *mockdb.MockStore) {
+				last := job
+				last.Attempts = last.MaxAttempts
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(1).Return(last, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(repo.User{}, sql.ErrConnDone)
+				store.EXPECT().RetryJob(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().KillJob(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.NoError(t, err)
+				require.True(t, processed)
+			},
+		},
+		{
+			name: "UnknownKind",
+			buildStubs: func(store *mockdb.MockStore) {
+				unknown := job
+				unknown.Kind = "unknown"
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(1).Return(unknown, nil)
+				store.EXPECT().KillJob(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.NoError(t, err)
+				require.True(t, processed)
+			},
+		},
+		{
+			name: "InvalidPayload",
+			buildStubs: func(store *mockdb.MockStore) {
+				invalid := job
+				invalid.Payload = []byte(`[]`)
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(1).Return(invalid, nil)
+				store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(0)
+				store.EXPECT().KillJob(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.NoError(t, err)
+				require.True(t, processed)
+			},
+		},
+		{
+			name: "ClaimError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), nil)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(1).Return(repo.Job{}, sql.ErrConnDone)
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.False(t, processed)
+			},
+		},
+		{
+			name: "StaleJobsKilled",
+			buildStubs: func(store *mockdb.MockStore) {
+				var staleBefore time.Time
+				store.EXPECT().
+					KillStaleJobs(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg time.Time) (int64, error) {
+						require.WithinDuration(t, time.Now().Add(-lockTimeout), arg, time.Second)
+						staleBefore = arg
+						return 2, nil
+					})
+				store.EXPECT().
+					ClaimJob(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ context.Context, arg time.Time) (repo.Job, error) {
+						// the same cutoff is used so a job is either killed or reclaimed, never neither
+						require.Equal(t, staleBefore, arg)
+						return repo.Job{}, repo.ErrRecordNotFound
+					})
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.NoError(t, err)
+				require.False(t, processed)
+			},
+		},
+		{
+			name: "KillStaleJobsError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).Times(1).Return(int64(0), sql.ErrConnDone)
+				store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(t *testing.T, processed bool, err error) {
+				require.ErrorIs(t, err, sql.ErrConnDone)
+				require.False(t, processed)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			processor, store, _ := newTestProcessor(t)
+			tc.buildStubs(store)
+
+			processed, err := processor.ProcessNext(context.Background())
+			tc.checkResponse(t, processed, err)
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	require.Equal(t, retryBaseDelay, backoff(1))
+	require.Equal(t, 2*retryBaseDelay, backoff(2))
+	require.Equal(t, 4*retryBaseDelay, backoff(3))
+	require.Equal(t, retryMaxDelay, backoff(100))
+}
+
+func TestStart(t *testing.T) {
+	processor, store, _ := newTestProcessor(t)
+	store.EXPECT().KillStaleJobs(gomock.Any(), gomock.Any()).MinTimes(1).Return(int64(0), nil)
+	store.EXPECT().ClaimJob(gomock.Any(), gomock.Any()).MinTimes(1).Return(repo.Job{}, repo.ErrRecordNotFound)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := processor.Start(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/simplebank/mail"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
+)
+
+const TaskSendPasswordReset = "send_password_reset"
+
+// PayloadSendPasswordReset asks for a password reset token to be sent to the user
+type PayloadSendPasswordReset struct {
+	Username string `json:"username"`
+}
+
+// EnqueueSendPasswordReset queues a password reset email for the user
+func EnqueueSendPasswordReset(ctx context.Context, q repo.Querier, payload PayloadSendPasswordReset) error {
+	return enqueue(ctx, q, TaskSendPasswordReset, payload)
+}
+
+func (processor *Processor) processSendPasswordReset(ctx context.Context, data json.RawMessage) error {
+	var payload PayloadSendPasswordReset
+	if err := decodePayload(data, &payload); err != nil {
+		return err
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return fmt.Errorf("user %s doesn't exist: %w", payload.Username, errSkipRetry)
+		}
+		return err
+	}
+
+	resetToken, err := token.NewSecretToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = processor.store.CreatePasswordResetToken(ctx, repo.CreatePasswordResetTokenParams{
+		Username:  user.Username,
+		TokenHash: token.HashSecretToken(resetToken),
+		ExpiresAt: time.Now().Add(processor.appConfig.PasswordResetTokenDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return processor.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nuse the token below to choose a new password, it expires in %s.\n"+
+				"If you didn't ask for a password reset you can ignore this email.\n\n%s\n",
+			user.FullName, processor.appConfig.PasswordResetTokenDuration, resetToken,
+		),
+	})
+}
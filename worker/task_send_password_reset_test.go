@@ -0,0 +1,59 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+)
+
+func TestProcessSendPasswordReset(t *testing.T) {
+	processor, store, mailer := newTestProcessor(t)
+
+	user := repo.User{
+		Username: testutils.RandomOwner(),
+		FullName: testutils.RandomOwner(),
+		Email:    testutils.RandomEmail(),
+	}
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().
+		CreatePasswordResetToken(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg repo.CreatePasswordResetTokenParams) (repo.PasswordResetToken, error) {
+			require.Equal(t, user.Username, arg.Username)
+			require.Len(t, arg.TokenHash, 64)
+			return repo.PasswordResetToken{}, nil
+		})
+
+	payload, err := json.Marshal(PayloadSendPasswordReset{Username: user.Username})
+	require.NoError(t, err)
+
+	err = processor.processSendPasswordReset(context.Background(), payload)
+	require.NoError(t, err)
+
+	require.Len(t, mailer.sent, 1)
+	require.Equal(t, user.Email, mailer.sent[0].To)
+}
+
+func TestProcessSendPasswordResetMailerError(t *testing.T) {
+	processor, store, mailer := newTestProcessor(t)
+	mailer.err = errors.New("smtp unavailable")
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(repo.User{Username: "user"}, nil)
+	store.EXPECT().CreatePasswordResetToken(gomock.Any(), gomock.Any()).Times(1)
+
+	payload, err := json.Marshal(PayloadSendPasswordReset{Username: "user"})
+	require.NoError(t, err)
+
+	// delivery failures are retried
+	err = processor.processSendPasswordReset(context.Background(), payload)
+	require.ErrorIs(t, err, mailer.err)
+	require.NotErrorIs(t, err, errSkipRetry)
+}
@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/simplebank/mail"
+	"github.com/simplebank/repo"
+)
+
+const TaskSendTransferNotification = "send_transfer_notification"
+
+// PayloadSendTransferNotification asks for the owner of the receiving account to be told about a transfer
+type PayloadSendTransferNotification struct {
+	TransferID int64 `json:"transfer_id"`
+}
+
+// EnqueueSendTransferNotification queues a notification about an incoming transfer
+func EnqueueSendTransferNotification(ctx context.Context, q repo.Querier, payload PayloadSendTransferNotification) error {
+	return enqueue(ctx, q, TaskSendTransferNotification, payload)
+}
+
+func (processor *Processor) processSendTransferNotification(ctx context.Context, data json.RawMessage) error {
+	var payload PayloadSendTransferNotification
+	if err := decodePayload(data, &payload); err != nil {
+		return err
+	}
+
+	transfer, err := processor.store.GetTransfer(ctx, payload.TransferID)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return fmt.Errorf("transfer %d doesn't exist: %w", payload.TransferID, errSkipRetry)
+		}
+		return err
+	}
+
+	toAccount, err := processor.store.GetAccount(ctx, transfer.ToAccountID)
+	if err != nil {
+		return err
+	}
+
+	owner, err := processor.store.GetUser(ctx, toAccount.Owner)
+	if err != nil {
+		return err
+	}
+
+	return processor.mailer.Send(ctx, mail.Message{
+		To:      owner.Email,
+		Subject: "You received a transfer",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\naccount #%d received %s from account #%d.\n",
+			owner.FullName, toAccount.ID, transfer.ReceivedAmount(), transfer.FromAccountID,
+		),
+	})
+}
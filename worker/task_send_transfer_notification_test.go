@@ -0,0 +1,64 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+)
+
+func TestProcessSendTransferNotification(t *testing.T) {
+	processor, store, mailer := newTestProcessor(t)
+
+	owner := repo.User{
+		Username: testutils.RandomOwner(),
+		FullName: testutils.RandomOwner(),
+		Email:    testutils.RandomEmail(),
+	}
+	toAccount := repo.Account{
+		ID:       testutils.RandomInt(1, 1000),
+		Owner:    owner.Username,
+		Currency: testutils.USD,
+	}
+	transfer := repo.Transfer{
+		ID:            testutils.RandomInt(1, 1000),
+		FromAccountID: testutils.RandomInt(1, 1000),
+		ToAccountID:   toAccount.ID,
+		Amount:        1234,
+		ToAmount:      1234,
+		Currency:      testutils.USD,
+		ToCurrency:    testutils.USD,
+	}
+
+	store.EXPECT().GetTransfer(gomock.Any(), gomock.Eq(transfer.ID)).Times(1).Return(transfer, nil)
+	store.EXPECT().GetAccount(gomock.Any(), gomock.Eq(toAccount.ID)).Times(1).Return(toAccount, nil)
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(owner.Username)).Times(1).Return(owner, nil)
+
+	payload, err := json.Marshal(PayloadSendTransferNotification{TransferID: transfer.ID})
+	require.NoError(t, err)
+
+	err = processor.processSendTransferNotification(context.Background(), payload)
+	require.NoError(t, err)
+
+	require.Len(t, mailer.sent, 1)
+	require.Equal(t, owner.Email, mailer.sent[0].To)
+	require.Contains(t, mailer.sent[0].Body, "12.34 USD")
+}
+
+func TestProcessSendTransferNotificationNotFound(t *testing.T) {
+	processor, store, mailer := newTestProcessor(t)
+
+	store.EXPECT().GetTransfer(gomock.Any(), gomock.Any()).Times(1).Return(repo.Transfer{}, repo.ErrRecordNotFound)
+
+	payload, err := json.Marshal(PayloadSendTransferNotification{TransferID: 1})
+	require.NoError(t, err)
+
+	err = processor.processSendTransferNotification(context.Background(), payload)
+	require.ErrorIs(t, err, errSkipRetry)
+	require.Empty(t, mailer.sent)
+}
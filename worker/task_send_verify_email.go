@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/simplebank/mail"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
+)
+
+const TaskSendVerifyEmail = "send_verify_email"
+
+// PayloadSendVerifyEmail asks for a verification token to be sent to Email,
+// which is either the current or the pending address of the user
+type PayloadSendVerifyEmail struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// EnqueueSendVerifyEmail queues a verification email for the user
+func EnqueueSendVerifyEmail(ctx context.Context, q repo.Querier, payload PayloadSendVerifyEmail) error {
+	return enqueue(ctx, q, TaskSendVerifyEmail, payload)
+}
+
+func (processor *Processor) processSendVerifyEmail(ctx context.Context, data json.RawMessage) error {
+	var payload PayloadSendVerifyEmail
+	if err := decodePayload(data, &payload); err != nil {
+		return err
+	}
+
+	user, err := processor.store.GetUser(ctx, payload.Username)
+	if err != nil {
+		if errors.Is(err, repo.ErrRecordNotFound) {
+			return fmt.Errorf("user %s doesn't exist: %w", payload.Username, errSkipRetry)
+		}
+		return err
+	}
+
+	verifyToken, err := token.NewSecretToken()
+	if err != nil {
+		return err
+	}
+
+	_, err = processor.store.CreateVerifyEmail(ctx, repo.CreateVerifyEmailParams{
+		Username:  user.Username,
+		Email:     payload.Email,
+		TokenHash: token.HashSecretToken(verifyToken),
+		ExpiresAt: time.Now().Add(processor.appConfig.VerifyEmailTokenDuration),
+	})
+	if err != nil {
+		return err
+	}
+
+	return processor.mailer.Send(ctx, mail.Message{
+		To:      payload.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nuse the token below to verify your email address, it expires in %s.\n\n%s\n",
+			user.FullName, processor.appConfig.VerifyEmailTokenDuration, verifyToken,
+		),
+	})
+}
@@ -0,0 +1,67 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/simplebank/internal/testutils"
+	"github.com/simplebank/repo"
+	"github.com/simplebank/token"
+)
+
+func TestProcessSendVerifyEmail(t *testing.T) {
+	processor, store, mailer := newTestProcessor(t)
+
+	user := repo.User{
+		Username: testutils.RandomOwner(),
+		FullName: testutils.RandomOwner(),
+		Email:    testutils.RandomEmail(),
+	}
+	pendingEmail := testutils.RandomEmail()
+
+	var tokenHash string
+	store.EXPECT().GetUser(gomock.Any(), gomock.Eq(user.Username)).Times(1).Return(user, nil)
+	store.EXPECT().
+		CreateVerifyEmail(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg repo.CreateVerifyEmailParams) (repo.VerifyEmail, error) {
+			require.Equal(t, user.Username, arg.Username)
+			require.Equal(t, pendingEmail, arg.Email)
+			require.WithinDuration(t, time.Now().Add(time.Hour), arg.ExpiresAt, time.Second)
+			tokenHash = arg.TokenHash
+			return repo.VerifyEmail{}, nil
+		})
+
+	payload, err := json.Marshal(PayloadSendVerifyEmail{Username: user.Username, Email: pendingEmail})
+	require.NoError(t, err)
+
+	err = processor.processSendVerifyEmail(context.Background(), payload)
+	require.NoError(t, err)
+
+	require.Len(t, mailer.sent, 1)
+	require.Equal(t, pendingEmail, mailer.sent[0].To)
+
+	// only the hash is stored, the token itself is in the email
+	lines := strings.Split(strings.TrimSpace(mailer.sent[0].Body), "\n")
+	require.Equal(t, tokenHash, token.HashSecretToken(lines[len(lines)-1]))
+}
+
+func TestProcessSendVerifyEmailUserNotFound(t *testing.T) {
+	processor, store, mailer := newTestProcessor(t)
+
+	store.EXPECT().GetUser(gomock.Any(), gomock.Any()).Times(1).Return(repo.User{}, repo.ErrRecordNotFound)
+	store.EXPECT().CreateVerifyEmail(gomock.Any(), gomock.Any()).Times(0)
+
+	payload, err := json.Marshal(PayloadSendVerifyEmail{Username: "user", Email: testutils.RandomEmail()})
+	require.NoError(t, err)
+
+	err = processor.processSendVerifyEmail(context.Background(), payload)
+	require.ErrorIs(t, err, errSkipRetry)
+	require.Empty(t, mailer.sent)
+}